@@ -1,41 +1,71 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net/netip"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"bhv.sh/minefetch/internal/flag"
-	"bhv.sh/minefetch/internal/mc"
+	"bhv.sh/minefetch/internal/retry"
 	"bhv.sh/minefetch/internal/term"
+	"bhv.sh/minefetch/mc"
 )
 
 var cfg = struct {
-	help    bool
-	version bool
-	host    string
-	port    uint16
-	timeout time.Duration
-	proto   int32
-	status  bool
-	bedrock struct {
+	help     bool
+	version  bool
+	host     string
+	port     uint16
+	timeout  time.Duration
+	timeouts struct {
+		status, bedrock, query, blocked, cracked, rcon, pack time.Duration
+	}
+	retry struct {
+		count   uint
+		backoff time.Duration
+	}
+	statusRetry bool
+	count       uint
+	interval    time.Duration
+	proto       int32
+	status      bool
+	bedrock     struct {
 		enabled bool
 		port    uint16
 	}
 	crossplay bool
+	raknet    bool
 	query     struct {
 		enabled bool
 		port    uint16
 	}
 	cracked bool
+	pack    bool
 	blocked bool
 	rcon    struct {
 		enabled bool
 		port    uint16
 	}
+	proxy struct {
+		version string
+		source  string
+		header  mc.ProxyHeader
+	}
+	diagnose bool
+	trace    bool
+	record   string
+	replay   string
+	from     struct {
+		file  string
+		stdin bool
+	}
+	lan    bool
 	output string
 	color  string
 	icon   struct {
@@ -54,6 +84,16 @@ var cfg = struct {
 		port    uint16
 	}{port: 19132},
 	timeout: time.Second,
+	// Downloads take longer than a request, and resource packs may be hundreds of megabytes
+	timeouts: struct {
+		status, bedrock, query, blocked, cracked, rcon, pack time.Duration
+	}{blocked: 10 * time.Second, pack: 30 * time.Second},
+	retry: struct {
+		count   uint
+		backoff time.Duration
+	}{count: 2, backoff: retry.DefaultBackoff},
+	count:    1,
+	interval: time.Second,
 	rcon: struct {
 		enabled bool
 		port    uint16
@@ -73,6 +113,7 @@ func printHelp() {
         minefetch
         minefetch [host] [port]
         minefetch [host[:port]]
+        minefetch lan
 Flags:
 `)
 	flag.Print()
@@ -84,18 +125,40 @@ func parseArgs() (err error) {
 	flag.Var(&cfg.help, "help", 'h', cfg.help, "Print usage information.")
 	flag.Var(&cfg.version, "version", 0, cfg.help, "Print Minefetch version.")
 	flag.Var(&cfg.timeout, "timeout", 't', cfg.timeout, "Maximum time to wait for a response before timing out.")
+	flag.Var(&cfg.timeouts.status, "status-timeout", 0, "timeout", "Timeout of the status request and each ping.")
+	flag.Var(&cfg.timeouts.bedrock, "bedrock-timeout", 0, "timeout", "Timeout of Bedrock pings and each RakNet handshake step.")
+	flag.Var(&cfg.timeouts.query, "query-timeout", 0, "timeout", "Timeout of each query request.")
+	flag.Var(&cfg.timeouts.blocked, "blocked-timeout", 0, cfg.timeouts.blocked, "Timeout of the blocklist download.")
+	flag.Var(&cfg.timeouts.cracked, "cracked-timeout", 0, "timeout", "Timeout of the login attempt.")
+	flag.Var(&cfg.timeouts.rcon, "rcon-timeout", 0, "timeout", "Timeout of the RCON check.")
+	flag.Var(&cfg.timeouts.pack, "pack-timeout", 0, cfg.timeouts.pack, "Timeout of the resource pack downloads.")
+	flag.Var(&cfg.retry.count, "retries", 0, cfg.retry.count, "Number of times to resend unanswered query requests and Bedrock pings.")
+	flag.Var(&cfg.retry.backoff, "retry-backoff", 0, cfg.retry.backoff, "Time to wait before the first retry, doubled for each following retry.")
+	flag.Var(&cfg.statusRetry, "status-retry", 0, cfg.statusRetry, "Send the status request again if the first one fails.")
+	flag.Var(&cfg.count, "count", 'n', cfg.count, "Number of pings to send for latency statistics.")
+	flag.Var(&cfg.interval, "interval", 0, cfg.interval, "Time to wait between pings.")
 	flag.Var(&proto, "proto", 'p', proto, "Protocol version to use for requests.")
 	flag.Var(&cfg.status, "no-status", 'S', cfg.status, "Don't get server info using the Server List Ping interface.")
 	flag.Var(&cfg.bedrock.enabled, "bedrock", 'b', cfg.bedrock.enabled, "Get Bedrock server info.")
 	flag.Var(&cfg.bedrock.port, "bedrock-port", 0, cfg.bedrock.port, "Bedrock server port.")
+	flag.Var(&cfg.raknet, "raknet", 0, cfg.raknet, "Check if a Bedrock server accepts connections using the RakNet handshake.")
 	flag.Var(&cfg.crossplay, "no-crossplay", 'C', cfg.crossplay, "Don't check if a Bedrock server is running on the same host.")
 	flag.Var(&cfg.query.enabled, "query", 'q', cfg.query.enabled, "Get server info using the query protocol.")
 	flag.Var(&cfg.query.port, "query-port", 0, "auto", "Query protocol port.")
 	flag.Var(&cfg.blocked, "blocked", 'x', cfg.blocked, "Check the host against Mojang's blocklist.")
 	flag.Var(&cfg.cracked, "cracked", 'c', cfg.cracked, "Attempt to login using an offline player.")
+	flag.Var(&cfg.pack, "download-pack", 0, cfg.pack, "Download the resource packs sent after the login to verify their hash and read their metadata.")
 	flag.Var(&cfg.rcon.enabled, "rcon", 'r', cfg.rcon.enabled, "Check if the RCON protocol is enabled.")
 	flag.Var(&cfg.rcon.port, "rcon-port", 0, cfg.rcon.port, "RCON protocol port.")
-	flag.Var(&cfg.output, "output", 'o', cfg.output, "Output format. (print, raw)")
+	flag.Var(&cfg.proxy.version, "proxy-protocol", 0, "off", "Send a PROXY protocol header before each request. (v1, v2)")
+	flag.Var(&cfg.proxy.source, "proxy-source", 0, "local address", "Source address to send in the PROXY protocol header.")
+	flag.Var(&cfg.diagnose, "diagnose", 'd', cfg.diagnose, "Run each request step by step and print the timing of each step.")
+	flag.Var(&cfg.trace, "trace", 0, cfg.trace, "Print every packet sent and received to stderr.")
+	flag.Var(&cfg.record, "record", 0, "off", "Save everything exchanged with the server to a file, to reproduce the output with --replay.")
+	flag.Var(&cfg.replay, "replay", 0, "off", "Print the output of a file saved with --record without connecting to the server.")
+	flag.Var(&cfg.from.file, "from-file", 0, "off", "Print a status JSON, Bedrock pong string or query response saved with --output raw, without connecting to the server.")
+	flag.Var(&cfg.from.stdin, "from-stdin", 0, cfg.from.stdin, "Like --from-file, but read the response from standard input.")
+	flag.Var(&cfg.output, "output", 'o', cfg.output, "Output format. (print, plain, json, markdown, html, raw)")
 	flag.Var(&cfg.color, "color", 0, "auto", "Override terminal color support detection. (0, 16, 256, true)")
 	flag.Var(&cfg.icon.enabled, "no-icon", 'I', cfg.icon.enabled, "Don't print the server icon.")
 	flag.Var(&cfg.icon.format, "icon", 'i', "auto", "Icon print format. Sixel suport is experimental. (half, sixel)")
@@ -108,6 +171,26 @@ func parseArgs() (err error) {
 		return
 	}
 
+	switch {
+	case cfg.record != "" && cfg.replay != "":
+		return errors.New("--record and --replay cannot be used together")
+	case cfg.record != "":
+		session = newCapture(recordArgs(os.Args[1:]))
+	case cfg.replay != "":
+		session, err = loadCapture(cfg.replay)
+		if err != nil {
+			return
+		}
+		// Flags given now override the recorded ones
+		args, err = flag.ParseArgs(slices.Concat(session.Args, os.Args[1:]))
+		if err != nil {
+			return
+		}
+		// The blocklist is not recorded, and TLS connections of resource pack downloads cannot be replayed
+		cfg.blocked = false
+		cfg.pack = false
+	}
+
 	if cfg.help {
 		printHelp()
 	}
@@ -119,7 +202,6 @@ func parseArgs() (err error) {
 
 	if cfg.bedrock.enabled {
 		cfg.status = false
-		cfg.query.enabled = false
 		cfg.cracked = false
 		cfg.rcon.enabled = false
 	}
@@ -128,7 +210,40 @@ func parseArgs() (err error) {
 		cfg.crossplay = false
 	}
 
-	if cfg.output != "print" && cfg.output != "raw" {
+	if cfg.count == 0 {
+		return errors.New("count must be at least 1")
+	}
+
+	if cfg.diagnose && session != nil {
+		return errors.New("--record and --replay cannot be used with --diagnose")
+	}
+
+	if offline() {
+		switch {
+		case cfg.from.file != "" && cfg.from.stdin:
+			return errors.New("--from-file and --from-stdin cannot be used together")
+		case session != nil:
+			return errors.New("--from-file and --from-stdin cannot be used with --record or --replay")
+		case cfg.diagnose:
+			return errors.New("--from-file and --from-stdin cannot be used with --diagnose")
+		case len(args) != 0:
+			return errors.New("--from-file and --from-stdin cannot be used with an address")
+		}
+	}
+
+	err = parseFlagProxy()
+	if err != nil {
+		return
+	}
+
+	switch cfg.output {
+	case "print", "raw":
+		// break
+	case "plain", "json", "markdown", "html":
+		// Fields must not contain escape codes
+		cfg.color = "never"
+		cfg.icon.enabled = false
+	default:
 		return fmt.Errorf("invalid output: %v", cfg.output)
 	}
 
@@ -171,6 +286,16 @@ func parseArgs() (err error) {
 		}
 	}
 
+	if len(args) == 1 && args[0] == "lan" {
+		if session != nil {
+			return errors.New("--record and --replay are not supported in LAN mode")
+		}
+		cfg.lan = true
+		cfg.crossplay = false
+		cfg.blocked = false
+		args = nil
+	}
+
 	var port uint16
 	switch len(args) {
 	case 0:
@@ -204,7 +329,10 @@ func parseArgs() (err error) {
 		}
 	}
 
-	cfg.proto = parseFlagProto(proto)
+	// Left at 0 by default, so that requests can use the server's version
+	if proto != "latest" {
+		cfg.proto = parseFlagProto(proto)
+	}
 
 	return
 }
@@ -222,3 +350,33 @@ func parseFlagProto(proto string) int32 {
 
 	return int32(i)
 }
+
+func parseFlagProxy() error {
+	switch cfg.proxy.version {
+	case "":
+		if cfg.proxy.source != "" {
+			return errors.New("--proxy-source requires --proxy-protocol")
+		}
+		return nil
+	case "v1", "1":
+		cfg.proxy.header.Version = 1
+	case "v2", "2":
+		cfg.proxy.header.Version = 2
+	default:
+		return fmt.Errorf("invalid PROXY protocol version: %v", cfg.proxy.version)
+	}
+
+	if cfg.proxy.source == "" {
+		return nil
+	}
+	source, err := netip.ParseAddrPort(cfg.proxy.source)
+	if err != nil {
+		addr, err := netip.ParseAddr(cfg.proxy.source)
+		if err != nil {
+			return fmt.Errorf("invalid PROXY source address: %v", cfg.proxy.source)
+		}
+		source = netip.AddrPortFrom(addr, 0)
+	}
+	cfg.proxy.header.Source = source
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"bhv.sh/minefetch/internal/term"
+	"bhv.sh/minefetch/mc"
+	"bhv.sh/minefetch/mcpe"
+)
+
+// Floodgate prefixes Bedrock player names with a character Java names cannot contain, "." by default.
+const floodgatePrefixes = ".*"
+
+// crossplay is the evidence linking a Bedrock server to the Java server on the same host.
+type crossplay struct {
+	geyser    string // How Geyser was detected, if it was
+	floodgate bool
+	motd      bool
+	players   bool
+	port      uint16
+	online    int
+	max       int
+	// Sampled players with a Floodgate prefix
+	bedrockPlayers, sampledPlayers int
+}
+
+func newCrossplay(results *results) (c crossplay) {
+	bedrock := get(results, bedrockProbe).v
+	query := get(results, queryProbe)
+	c.port = bedrock.Port.IPv4
+	c.online, c.max = bedrock.Players.Online, bedrock.Players.Max
+
+	var plugins []string
+	var sample []string
+	if query.success {
+		plugins = query.v.Plugins
+		sample = query.v.Players.Sample
+	}
+	for _, p := range plugins {
+		name := strings.ToLower(p)
+		switch {
+		case strings.HasPrefix(name, "geyser"):
+			c.geyser = "query plugins"
+		case strings.HasPrefix(name, "floodgate"):
+			c.floodgate = true
+		}
+	}
+	if c.geyser == "" {
+		if sw, ok := mcpe.Fingerprint(bedrock, nil); ok && sw.Name == mcpe.SoftwareGeyser {
+			c.geyser = "pong"
+		}
+	}
+
+	if result := get(results, statusProbe); result.success {
+		status := result.v
+		c.players = status.Players.Online == bedrock.Players.Online && status.Players.Max == bedrock.Players.Max
+		// Geyser passes the Java MOTD through as the Bedrock name and level
+		for line := range strings.Lines(mc.LegacyTextPlain(status.Motd.Raw())) {
+			line = normalizeMotd(line)
+			if line != "" && (line == normalizeMotd(bedrock.Name) || line == normalizeMotd(bedrock.Level)) {
+				c.motd = true
+			}
+		}
+		if sample == nil {
+			for _, p := range status.Players.Sample {
+				sample = append(sample, p.Name)
+			}
+		}
+	}
+
+	c.sampledPlayers = len(sample)
+	for _, name := range sample {
+		if isFloodgateName(name) {
+			c.bedrockPlayers++
+		}
+	}
+	return
+}
+
+// sameNetwork reports whether the Bedrock server is likely a bridge to the Java server,
+// and whether there is enough evidence either way.
+func (c crossplay) sameNetwork() (same, sure bool) {
+	switch {
+	case c.geyser != "" || c.floodgate || c.bedrockPlayers > 0:
+		return true, true
+	case c.motd && c.players:
+		return true, true
+	case c.motd || c.players:
+		return true, false
+	}
+	return false, false
+}
+
+func crossplayFields(s *section, c crossplay) {
+	same, sure := c.sameNetwork()
+	switch {
+	case same && sure:
+		s.addSeverity(severityGood, "Crossplay", term.Green+"Yes "+term.Gray+"(same network)")
+	case same:
+		s.addSeverity(severityGood, "Crossplay", term.Green+"Yes "+term.Gray+"(probably the same network)")
+	default:
+		s.addSeverity(severityWarn, "Crossplay", term.DarkYellow+"Unlikely "+term.Gray+"(the Bedrock server on the same host seems unrelated)")
+	}
+
+	var bridge []string
+	if c.geyser != "" {
+		bridge = append(bridge, "Geyser "+term.Gray+"(from "+c.geyser+")"+term.Reset)
+	}
+	if c.floodgate {
+		bridge = append(bridge, "Floodgate "+term.Gray+"(from query plugins)"+term.Reset)
+	}
+	if len(bridge) > 0 {
+		s.add("Bridge", strings.Join(bridge, "\n"))
+	}
+
+	if c.port != 0 && c.port != cfg.bedrock.port {
+		s.addSeverity(severityWarn, "Advertised port", fmt.Sprintf("%v "+term.DarkYellow+"(differs from the Bedrock port)", c.port))
+	}
+
+	s.add("Bedrock MOTD", formatBool(c.motd, "Matches Java", "Differs from Java"))
+
+	v := fmt.Sprintf("%v"+term.Gray+"/"+term.Reset+"%v ", c.online, c.max) + formatBool(c.players, "Matches Java", "Differs from Java")
+	if c.sampledPlayers > 0 {
+		v += fmt.Sprintf("\n"+term.Reset+"%v "+term.Gray+"of %v sampled with a Floodgate prefix", c.bedrockPlayers, c.sampledPlayers)
+	}
+	s.add("Bedrock players", v)
+}
+
+// normalizeMotd trims and lowercases s and collapses its spaces for comparison.
+func normalizeMotd(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(mc.LegacyTextPlain(s)), " "))
+}
+
+// isFloodgateName reports whether name is a valid Java player name with a Floodgate prefix.
+func isFloodgateName(name string) bool {
+	if len(name) < 2 || !strings.ContainsRune(floodgatePrefixes, rune(name[0])) {
+		return false
+	}
+	name = name[1:]
+	if len(name) > 16 {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
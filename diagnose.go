@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"bhv.sh/minefetch/internal/term"
+	"bhv.sh/minefetch/mc"
+)
+
+func printDiagnosis() {
+	ctx := context.Background()
+	client := newClient()
+	if cfg.trace {
+		client.Trace = tracer("diagnose")
+	}
+	if cfg.status {
+		address := cfg.host
+		if cfg.port != 0 {
+			address = mc.JoinHostPort(cfg.host, cfg.port)
+		}
+		printStages("Java status", client.DiagnoseStatus(ctx, address))
+	}
+	if cfg.bedrock.enabled {
+		printStages("Bedrock status", client.DiagnoseBedrock(ctx, mc.JoinHostPort(cfg.host, cfg.bedrock.port)))
+	}
+	if cfg.query.enabled {
+		printStages("Query", client.DiagnoseQuery(ctx, queryAddress()))
+	}
+}
+
+func printStages(title string, results []mc.StepResult) {
+	width := len("Total")
+	for _, r := range results {
+		width = max(width, len(r.Stage))
+	}
+
+	fmt.Println(term.Bold + term.Blue + title + term.Reset)
+	var total time.Duration
+	for _, r := range results {
+		total += r.Duration
+		var s string
+		if r.Err == nil {
+			s = term.Green + "OK    " + term.Reset
+		} else {
+			s = term.DarkYellow + "Failed" + term.Reset
+		}
+		s += fmt.Sprintf(" %9v", formatDuration(r.Duration))
+		if r.Err != nil {
+			s += " " + term.Gray + "(" + term.Sanitize(r.Err.Error()) + ")" + term.Reset
+		} else if r.Info != "" {
+			s += " " + term.Gray + term.Sanitize(r.Info) + term.Reset
+		}
+		fmt.Printf("  %-*s  %v\n", width, r.Stage, s)
+	}
+	fmt.Printf("  %-*s  %6s %9v\n\n", width, "Total", "", formatDuration(total))
+}
+
+func formatDuration(d time.Duration) string {
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(d.Microseconds())/1000), ".0") + " ms"
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"bhv.sh/minefetch/internal/term"
+	"bhv.sh/minefetch/mc"
+)
+
+// insideFields adds what the server sent after letting the cracked login in,
+// and the resource packs downloaded from what it sent.
+func insideFields(s *section, login mc.LoginResponse, downloads result[[]packDownload]) {
+	if login.Compression < 0 {
+		s.add("Compression", "Off")
+	} else {
+		s.add("Compression", fmt.Sprintf("%v bytes "+term.Gray+"(threshold)", login.Compression))
+	}
+
+	config := login.Config
+	if config == nil {
+		s.add("Configuration", term.Gray+"None (added in 1.20.2)")
+		return
+	}
+
+	if config.Brand != "" {
+		s.add("Brand", mc.LegacyTextAnsi(config.Brand))
+	}
+
+	if len(config.FeatureFlags) > 0 {
+		s.add("Feature flags", term.Sanitize(strings.Join(config.FeatureFlags, "\n")))
+	}
+
+	if len(config.KnownPacks) > 0 {
+		packs := make([]string, 0, len(config.KnownPacks))
+		for _, p := range config.KnownPacks {
+			packs = append(packs, term.Sanitize(p.Namespace+":"+p.ID)+" "+term.Gray+term.Sanitize(p.Version))
+		}
+		s.add("Known packs", strings.Join(packs, "\n"))
+	}
+
+	if len(config.Registries) > 0 {
+		// Entries are counted by namespace, as other namespaces come from data packs and mods
+		namespaces := map[string]int{}
+		entries := 0
+		for _, ids := range config.Registries {
+			for _, id := range ids {
+				namespace, _, ok := strings.Cut(id, ":")
+				if !ok {
+					namespace = "minecraft"
+				}
+				namespaces[namespace]++
+				entries++
+			}
+		}
+		v := fmt.Sprintf("%v registries, %v entries", len(config.Registries), entries)
+		for _, namespace := range slices.Sorted(maps.Keys(namespaces)) {
+			v += fmt.Sprintf("\n"+term.Reset+"%v "+term.Gray+"(%v entries)", term.Sanitize(namespace), namespaces[namespace])
+		}
+		s.add("Registries", v)
+	}
+
+	if len(config.ServerLinks) > 0 {
+		links := make([]string, 0, len(config.ServerLinks))
+		for _, l := range config.ServerLinks {
+			links = append(links, l.Label.Ansi()+" "+term.Gray+term.Sanitize(l.URL))
+		}
+		s.add("Links", strings.Join(links, "\n"))
+	}
+
+	if len(config.ReportDetails) > 0 {
+		details := make([]string, 0, len(config.ReportDetails))
+		for _, d := range config.ReportDetails {
+			details = append(details, term.Sanitize(d.Title)+term.Gray+": "+term.Sanitize(d.Description))
+		}
+		s.add("Report details", strings.Join(details, "\n"))
+	}
+
+	for _, p := range config.ResourcePacks {
+		v := term.Sanitize(p.URL)
+		if p.Required {
+			v += "\n" + term.Reset + "Required"
+		} else {
+			v += "\n" + term.Reset + "Optional"
+		}
+		if p.Prompt.Raw() != "" {
+			v += term.Gray + " (prompt: " + term.Reset + p.Prompt.Ansi() + term.Gray + ")"
+		}
+		if p.Hash != "" {
+			v += "\n" + term.Reset + "SHA-1 " + term.Gray + term.Sanitize(p.Hash)
+		} else {
+			v += "\n" + term.DarkYellow + "No SHA-1 hash; downloaded on every join"
+		}
+		s.add("Resource pack", v)
+	}
+
+	if cfg.pack && len(config.ResourcePacks) > 0 {
+		addResult(s, downloads, "Download", func(downloads []packDownload) {
+			for i, d := range downloads {
+				packFields(s, config.ResourcePacks[i], d)
+			}
+		}, "")
+	}
+
+	if config.CodeOfConduct != "" {
+		s.add("Code of conduct", mc.LegacyTextAnsi(config.CodeOfConduct))
+	}
+
+	if config.Err != nil {
+		addErr(s, "Configuration", config.Err)
+	}
+}
+
+// packFields adds the fields of a downloaded resource pack.
+// The size and hash are also shown if the download is not a valid pack.
+func packFields(s *section, pack mc.ResourcePack, d packDownload) {
+	info := d.info
+	if d.err != nil && info.Size == 0 && info.Hash == "" {
+		addErr(s, "Download", d.err)
+		return
+	}
+	v := formatSize(info.Size)
+	sev := severityNone
+	switch {
+	case info.Hash == "":
+	case pack.Hash == "":
+		v += "\n" + term.Reset + "SHA-1 " + term.Gray + info.Hash
+	case strings.EqualFold(pack.Hash, info.Hash):
+		v += "\n" + term.Green + "SHA-1 matches"
+		sev = severityGood
+	default:
+		v += "\n" + term.Red + "SHA-1 mismatch " + term.Gray + "(" + info.Hash + ")"
+		sev = severityBad
+	}
+	if d.err != nil {
+		v += "\n" + term.DarkYellow + "Failed " + formatErr("Download", d.err)
+		s.addSeverity(max(sev, severityWarn), "Download", v)
+		return
+	}
+	format := fmt.Sprint(info.Format)
+	if info.MinFormat != 0 || info.MaxFormat != 0 {
+		format += fmt.Sprintf(term.Gray+" (supports %v – %v)", info.MinFormat, info.MaxFormat)
+	}
+	v += "\n" + term.Reset + "Format " + format
+	if info.Description.Raw() != "" {
+		for _, line := range strings.Split(info.Description.Ansi(), "\n") {
+			v += "\n" + term.Reset + term.TrimSpace(line)
+		}
+	}
+	s.addSeverity(sev, "Download", v)
+}
+
+// formatSize returns n bytes in KiB or MiB.
+func formatSize(n int64) string {
+	if n < 1<<20 {
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+}
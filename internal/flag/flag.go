@@ -46,6 +46,12 @@ func Var(p any, name string, r rune, v any, usage string) {
 
 // Parse parses the command-line flags from os.Args[1:].
 func Parse() (remaining []string, err error) {
+	return ParseArgs(os.Args[1:])
+}
+
+// ParseArgs parses flags from args, which should not include the program name.
+// It may be called more than once, in which case later values override earlier ones.
+func ParseArgs(args []string) (remaining []string, err error) {
 	flagNameMap := make(map[string]Flag)
 	flagRuneMap := make(map[rune]Flag)
 	for _, f := range Flags {
@@ -57,7 +63,6 @@ func Parse() (remaining []string, err error) {
 		}
 	}
 
-	args := os.Args[1:]
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
 		skip := false
@@ -165,14 +170,16 @@ func Print() {
 		}
 		isBool := false
 		var s string
-		switch f.DefValue.(type) {
-		case bool:
+		// The default may be a description such as "auto", so the type is taken from the variable
+		switch f.Value.(type) {
+		case *bool:
 			s = f.Name
 			isBool = true
-		case time.Duration:
+		case *time.Duration:
 			s = f.Name + " duration"
 		default:
-			s = fmt.Sprintf("%s %T", f.Name, f.DefValue)
+			s = fmt.Sprintf("%s %T", f.Name, f.Value)
+			s = strings.Replace(s, "*", "", 1)
 		}
 		// Use of sentry character inspired by spf13/pflag
 		b.WriteString(fmt.Sprintf("--%s\x00%s", s, f.Usage))
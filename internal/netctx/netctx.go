@@ -0,0 +1,36 @@
+// Package netctx bounds connections by a context, for the Java and Bedrock clients alike.
+package netctx
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// CloseOnDone returns conn, which is closed when ctx is done.
+func CloseOnDone(ctx context.Context, conn net.Conn) net.Conn {
+	return &ctxConn{conn, context.AfterFunc(ctx, func() { conn.Close() })}
+}
+
+// ctxConn is a connection closed by a context.AfterFunc.
+type ctxConn struct {
+	net.Conn
+	stop func() bool
+}
+
+func (c *ctxConn) Close() error {
+	c.stop()
+	return c.Conn.Close()
+}
+
+// Deadline returns the earliest of ctx's deadline and timeout from now.
+// A timeout of 0 is ignored. It returns the zero time if there is neither.
+func Deadline(ctx context.Context, timeout time.Duration) (t time.Time) {
+	if timeout != 0 {
+		t = time.Now().Add(timeout)
+	}
+	if d, ok := ctx.Deadline(); ok && (t.IsZero() || d.Before(t)) {
+		t = d
+	}
+	return
+}
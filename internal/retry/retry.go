@@ -0,0 +1,58 @@
+// Package retry resends UDP requests that are not answered in time.
+package retry
+
+import (
+	"errors"
+	"net"
+	"os"
+	"time"
+)
+
+// DefaultBackoff is the time to wait before the first retry if Policy.Backoff is 0.
+const DefaultBackoff = 250 * time.Millisecond
+
+// Policy controls how often a request is resent.
+//
+// Count is the number of times a request is resent if no response arrives.
+// The first retry is sent Backoff after the request, and the wait doubles after each retry.
+// No retries are sent after the deadline of the exchange.
+type Policy struct {
+	Count   int
+	Backoff time.Duration
+}
+
+// Exchange calls send and then recv, which should read a single response from conn.
+//
+// If recv times out and retries remain, send is called again and recv is retried.
+// The read deadline of conn is set before each call to recv, and reset to deadline on return.
+func (p Policy) Exchange(conn net.Conn, deadline time.Time, send, recv func() error) (err error) {
+	defer conn.SetReadDeadline(deadline)
+
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+	for i := 0; ; i++ {
+		err = send()
+		if err != nil {
+			return
+		}
+
+		last := i >= p.Count
+		t := deadline
+		if !last {
+			t = time.Now().Add(backoff)
+			if !deadline.IsZero() && deadline.Before(t) {
+				t = deadline
+				last = true
+			}
+		}
+		conn.SetReadDeadline(t)
+
+		err = recv()
+		if last || !errors.Is(err, os.ErrDeadlineExceeded) {
+			return
+		}
+		backoff *= 2
+	}
+}
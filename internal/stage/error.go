@@ -0,0 +1,105 @@
+package stage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Kind classifies why a step failed.
+type Kind int
+
+const (
+	Unknown  Kind = iota
+	NotFound      // The domain does not exist (NXDOMAIN)
+	Refused       // Nothing is listening on the port
+	Reset         // The connection was reset or closed early
+	Timeout       // No response was received in time
+	Protocol      // The server sent data that does not follow the protocol
+	PacketId      // The server sent an unexpected packet
+	Json          // The server sent malformed JSON
+)
+
+// ErrProtocol is wrapped by errors caused by data that does not follow the protocol.
+var ErrProtocol = errors.New("protocol violation")
+
+// PacketIdError reports a packet with an unexpected ID.
+type PacketIdError struct {
+	Id int32
+}
+
+func (e *PacketIdError) Error() string {
+	return fmt.Sprint("unexpected packet ID: ", e.Id)
+}
+
+// Error is an error that occurred during a step.
+type Error struct {
+	Stage string
+	Kind  Kind
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return strings.ToLower(e.Stage) + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap returns err as an *Error that occurred during stage, classified by Classify.
+//
+// Failed lookups are attributed to the Lookup stage,
+// as connecting to a domain implicitly resolves it.
+// Wrap returns nil if err is nil, and err if it is already an *Error.
+func Wrap(stage string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		stage = Lookup
+	}
+	return &Error{stage, Classify(err), err}
+}
+
+// Classify determines the Kind of err.
+func Classify(err error) Kind {
+	var e *Error
+	var dnsErr *net.DNSError
+	var packetIdErr *PacketIdError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var numErr *strconv.NumError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &e):
+		return e.Kind
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return NotFound
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return Refused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return Reset
+	case errors.Is(err, os.ErrDeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return Timeout
+	case errors.As(err, &packetIdErr):
+		return PacketId
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return Json
+	case errors.Is(err, ErrProtocol), errors.As(err, &numErr):
+		return Protocol
+	}
+	return Unknown
+}
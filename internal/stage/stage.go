@@ -0,0 +1,41 @@
+// Package stage names the steps of server requests and records the outcome of each.
+package stage
+
+import "time"
+
+// Names of the steps shared by Java Edition and Bedrock Edition requests.
+const (
+	SrvLookup       = "SRV lookup"
+	Lookup          = "A/AAAA lookup"
+	Connect         = "Connect"
+	Handshake       = "Handshake"
+	StatusResponse  = "Status response"
+	Ping            = "Ping"
+	Login           = "Login"
+	Configuration   = "Configuration"
+	RconLogin       = "RCON login"
+	QueryHandshake  = "Query handshake"
+	QueryStatus     = "Query status"
+	Pong            = "Unconnected pong"
+	OpenConnection1 = "Open connection 1"
+	OpenConnection2 = "Open connection 2"
+)
+
+// Result is the outcome of a single step.
+//
+// Info is a short description of what the step found, e.g. a resolved address or a response size.
+type Result struct {
+	Stage    string
+	Duration time.Duration
+	Info     string
+	Err      error
+}
+
+// Run times fn and appends its outcome to results.
+// It reports whether fn succeeded, so that following steps can be skipped.
+func Run(results *[]Result, stage string, fn func() (info string, err error)) bool {
+	start := time.Now()
+	info, err := fn()
+	*results = append(*results, Result{stage, time.Since(start), info, err})
+	return err == nil
+}
@@ -42,3 +42,19 @@ func Back(n uint) string {
 	}
 	return "\033[" + strconv.Itoa(int(n)) + "D"
 }
+
+// ClearLine clears the line from the cursor to the end.
+func ClearLine() string {
+	if ColorSupport == NoColorSupport {
+		return ""
+	}
+	return "\033[K"
+}
+
+// ClearDown clears the screen from the cursor to the end.
+func ClearDown() string {
+	if ColorSupport == NoColorSupport {
+		return ""
+	}
+	return "\033[J"
+}
@@ -71,3 +71,85 @@ func TrimSpace(s string) string {
 
 	return b.String()
 }
+
+// Removed is the marker left by Sanitize where content was removed.
+const Removed = "\uFFFD"
+
+// Sanitize returns s without control characters and escape sequences,
+// so that text from untrusted sources cannot retitle the terminal, move the cursor, etc.
+//
+// Each removed character or sequence is replaced with Removed.
+// Newlines are kept and tabs are replaced with spaces.
+// Both the 7-bit (ESC) and 8-bit (C1) forms of sequences are recognized,
+// and invalid UTF-8 is replaced so that raw C1 bytes cannot reach the terminal.
+func Sanitize(s string) string {
+	if utf8.ValidString(s) && !strings.ContainsFunc(s, isControl) {
+		return s
+	}
+
+	const (
+		text = iota
+		esc  // After ESC
+		csi  // In a control sequence
+		str  // In a control string, such as OSC
+		strEsc
+	)
+	state := text
+	var b strings.Builder
+	for _, r := range s {
+		switch state {
+		case text:
+			switch {
+			case r == '\n':
+				b.WriteRune(r)
+			case r == '\t':
+				b.WriteRune(' ')
+			case r == 033:
+				state = esc
+				b.WriteString(Removed)
+			case r == 0x9b:
+				state = csi
+				b.WriteString(Removed)
+			case r == 0x90, r == 0x98, r == 0x9d, r == 0x9e, r == 0x9f:
+				state = str
+				b.WriteString(Removed)
+			case isControl(r):
+				b.WriteString(Removed)
+			default:
+				b.WriteRune(r)
+			}
+		case esc:
+			switch {
+			case r == '[':
+				state = csi
+			case r == 'P', r == 'X', r == ']', r == '^', r == '_':
+				state = str
+			case r >= 0x20 && r <= 0x2f:
+				// Intermediate bytes
+			default:
+				state = text
+			}
+		case csi:
+			if r >= 0x40 && r <= 0x7e || r == '\n' {
+				state = text
+			}
+		case str:
+			switch r {
+			case 033:
+				state = strEsc
+			case 0x07, 0x9c:
+				state = text
+			}
+		case strEsc:
+			state = str
+			if r == '\\' {
+				state = text
+			}
+		}
+	}
+	return b.String()
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 && r != '\n' || r >= 0x7f && r <= 0x9f
+}
@@ -0,0 +1,46 @@
+package term
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitize(t *testing.T) {
+	for _, c := range [][2]string{
+		{"A Minecraft Server", "A Minecraft Server"},
+		{"line\nbreak\ttab", "line\nbreak tab"},
+		// OSC 52 sets the clipboard
+		{"a\033]52;c;aGk=\007b", "a" + Removed + "b"},
+		{"a\033]52;c;aGk=\033\\b", "a" + Removed + "b"},
+		{"a\u009d52;c;aGk=\u009cb", "a" + Removed + "b"},
+		{"a\033[2J", "a" + Removed},
+		// Unterminated sequences remove the rest of the line
+		{"a\033[1;2", "a" + Removed},
+		{"a\033[1;2\nb", "a" + Removed + "b"},
+		{"a\033]0;title", "a" + Removed},
+		// 8-bit C1 CSI, as a rune and as a raw byte
+		{"a\u009b2Jb", "a" + Removed + "b"},
+		{"a\x9b2Jb", "a" + Removed + "2Jb"},
+		// SGR does not pass through either, since colors are added after sanitizing
+		{"\033[31mred\033[0m", Removed + "red" + Removed},
+		{"bell\007", "bell" + Removed},
+	} {
+		if got := Sanitize(c[0]); got != c[1] {
+			t.Errorf("Sanitize(%q) = %q, want %q", c[0], got, c[1])
+		}
+	}
+}
+
+func FuzzSanitize(f *testing.F) {
+	f.Add("\033]52;c;aGk=\007\033[31mred\u009b2J\x9b")
+	f.Fuzz(func(t *testing.T, s string) {
+		got := Sanitize(s)
+		if !utf8.ValidString(got) {
+			t.Errorf("Sanitize(%q) = %q, which is not valid UTF-8", s, got)
+		}
+		if strings.ContainsFunc(got, isControl) {
+			t.Errorf("Sanitize(%q) = %q, which contains control characters", s, got)
+		}
+	})
+}
@@ -4,3 +4,8 @@ package term
 func Size() (width, height uint, err error) {
 	return size()
 }
+
+// IsTerminal reports whether stdout is a terminal.
+func IsTerminal() bool {
+	return isTerminal()
+}
@@ -58,3 +58,8 @@ func makeRaw() (termios termios, err error) {
 	err = setState(newTermios)
 	return
 }
+
+func isTerminal() bool {
+	_, err := state()
+	return err == nil
+}
@@ -78,3 +78,8 @@ func makeRaw() (mode uint32, err error) {
 	err = setState(syscall.Stdin, newMode)
 	return
 }
+
+func isTerminal() bool {
+	_, err := state(syscall.Stdout)
+	return err == nil
+}
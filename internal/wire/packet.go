@@ -0,0 +1,322 @@
+package wire
+
+import (
+	"bytes"
+	"cmp"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"slices"
+
+	"bhv.sh/minefetch/internal/stage"
+)
+
+// Maximum packet lengths, as enforced by the client.
+//
+// Packets are limited by the 3 byte VarInt length prefix,
+// and decompressed packets to 8 MiB.
+const (
+	MaxPacketLength             = 1<<21 - 1
+	MaxUncompressedPacketLength = 1 << 23
+)
+
+// Packet IDs by state, sent by the client and then by the server.
+
+const HandshakePacketId int32 = 0
+
+const (
+	StatusPacketIdStatusRequest int32 = iota
+	StatusPacketIdPingRequest
+)
+const (
+	StatusPacketIdStatusResponse int32 = iota
+	StatusPacketIdPongResponse
+)
+
+const (
+	LoginPacketIdLoginStart int32 = iota
+	LoginPacketIdEncryptionResponse
+	LoginPacketIdLoginPluginResponse
+	LoginPacketIdLoginAcknowledged
+	LoginPacketIdCookieResponse
+)
+const (
+	LoginPacketIdDisconnect int32 = iota
+	LoginPacketIdEncryptionRequest
+	LoginPacketIdLoginSuccess
+	LoginPacketIdSetCompression
+	LoginPacketIdLoginPluginRequest
+	LoginPacketIdLoginCookieRequest
+)
+
+// Configuration packet IDs are those of 1.20.5 and later.
+// Packets sent in both directions are prefixed by their direction.
+const (
+	ConfigurationPacketIdClientInformation int32 = iota
+	ConfigurationPacketIdCookieResponse
+	ConfigurationPacketIdServerboundPluginMessage
+	ConfigurationPacketIdAcknowledgeFinishConfiguration
+	ConfigurationPacketIdServerboundKeepAlive
+	ConfigurationPacketIdPong
+	ConfigurationPacketIdResourcePackResponse
+	ConfigurationPacketIdServerboundKnownPacks
+	ConfigurationPacketIdCustomClickAction
+	ConfigurationPacketIdAcceptCodeOfConduct
+)
+const (
+	ConfigurationPacketIdCookieRequest int32 = iota
+	ConfigurationPacketIdClientboundPluginMessage
+	ConfigurationPacketIdDisconnect
+	ConfigurationPacketIdFinishConfiguration
+	ConfigurationPacketIdClientboundKeepAlive
+	ConfigurationPacketIdPing
+	ConfigurationPacketIdResetChat
+	ConfigurationPacketIdRegistryData
+	ConfigurationPacketIdRemoveResourcePack
+	ConfigurationPacketIdAddResourcePack
+	ConfigurationPacketIdStoreCookie
+	ConfigurationPacketIdTransfer
+	ConfigurationPacketIdFeatureFlags
+	ConfigurationPacketIdUpdateTags
+	ConfigurationPacketIdClientboundKnownPacks
+	ConfigurationPacketIdCustomReportDetails
+	ConfigurationPacketIdServerLinks
+	ConfigurationPacketIdClearDialog
+	ConfigurationPacketIdShowDialog
+	ConfigurationPacketIdCodeOfConduct
+)
+
+// The configuration state was added in 1.20.2 (protocol 764),
+// and its packet IDs changed in 1.20.3 (765) and 1.20.5 (766).
+// The packets of earlier protocols are listed by ID,
+// so that they are translated to and from the IDs above.
+var (
+	serverboundConfiguration764 = []int32{
+		ConfigurationPacketIdClientInformation,
+		ConfigurationPacketIdServerboundPluginMessage,
+		ConfigurationPacketIdAcknowledgeFinishConfiguration,
+		ConfigurationPacketIdServerboundKeepAlive,
+		ConfigurationPacketIdPong,
+		ConfigurationPacketIdResourcePackResponse,
+	}
+	clientboundConfiguration764 = []int32{
+		ConfigurationPacketIdClientboundPluginMessage,
+		ConfigurationPacketIdDisconnect,
+		ConfigurationPacketIdFinishConfiguration,
+		ConfigurationPacketIdClientboundKeepAlive,
+		ConfigurationPacketIdPing,
+		ConfigurationPacketIdRegistryData,
+		ConfigurationPacketIdAddResourcePack,
+		ConfigurationPacketIdFeatureFlags,
+		ConfigurationPacketIdUpdateTags,
+	}
+	clientboundConfiguration765 = []int32{
+		ConfigurationPacketIdClientboundPluginMessage,
+		ConfigurationPacketIdDisconnect,
+		ConfigurationPacketIdFinishConfiguration,
+		ConfigurationPacketIdClientboundKeepAlive,
+		ConfigurationPacketIdPing,
+		ConfigurationPacketIdRegistryData,
+		ConfigurationPacketIdRemoveResourcePack,
+		ConfigurationPacketIdAddResourcePack,
+		ConfigurationPacketIdFeatureFlags,
+		ConfigurationPacketIdUpdateTags,
+	}
+)
+
+// configurationIds returns the packets of protocol sent by the client if sent is true,
+// or nil if protocol uses the IDs of 1.20.5 and later.
+func configurationIds(protocol int32, sent bool) []int32 {
+	switch {
+	case protocol >= 766:
+		return nil
+	case sent:
+		return serverboundConfiguration764
+	case protocol == 764:
+		return clientboundConfiguration764
+	default:
+		return clientboundConfiguration765
+	}
+}
+
+// ConfigurationPacketId returns the ID that protocol uses for the configuration packet id of 1.20.5 and later,
+// or false if the packet does not exist in protocol.
+func ConfigurationPacketId(protocol int32, sent bool, id int32) (int32, bool) {
+	ids := configurationIds(protocol, sent)
+	if ids == nil {
+		return id, true
+	}
+	i := slices.Index(ids, id)
+	return int32(i), i >= 0
+}
+
+// LatestConfigurationPacketId returns the ID in 1.20.5 and later of the configuration packet id of protocol,
+// or false if id is unknown.
+func LatestConfigurationPacketId(protocol int32, sent bool, id int32) (int32, bool) {
+	ids := configurationIds(protocol, sent)
+	if ids == nil {
+		return id, true
+	}
+	if id < 0 || int(id) >= len(ids) {
+		return -1, false
+	}
+	return ids[id], true
+}
+
+// WritePacket writes p, which starts with the packet ID, prefixed by its length.
+//
+// https://minecraft.wiki/w/Java_Edition_protocol/Packets#Without_compression
+func WritePacket(w io.Writer, p []byte) error {
+	err := writePacket(w, p)
+	if err == nil {
+		tracePacket(w, p, len(p), 0)
+	}
+	return err
+}
+
+func writePacket(w io.Writer, p []byte) error {
+	buf := &bytes.Buffer{}
+	err1 := WriteVarInt(buf, int32(len(p)))
+	_, err2 := buf.Write(p)
+	if err := cmp.Or(err1, err2); err != nil {
+		return err
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ReadPacket reads a packet written by WritePacket,
+// and returns its ID and the rest of its data.
+func ReadPacket(r io.Reader) (id int32, buf *bytes.Buffer, err error) {
+	n, err := ReadPacketLength(r)
+	if err != nil {
+		return
+	}
+
+	buf = bytes.NewBuffer(make([]byte, n))
+	_, err = io.ReadFull(r, buf.Bytes())
+	if err != nil {
+		return
+	}
+
+	id, err = ReadVarInt(buf)
+	if err != nil {
+		return
+	}
+	traceFrame(r, false, id, buf.Bytes(), int(n), 0)
+
+	return
+}
+
+// ReadCompressedPacket is like ReadPacket, for packets sent after compression is enabled.
+//
+// https://minecraft.wiki/w/Java_Edition_protocol/Packets#With_compression
+func ReadCompressedPacket(r io.Reader) (id int32, buf *bytes.Buffer, err error) {
+	n, err := ReadPacketLength(r)
+	if err != nil {
+		return
+	}
+
+	zbuf := bytes.NewBuffer(make([]byte, n))
+	_, err = io.ReadFull(r, zbuf.Bytes())
+	if err != nil {
+		return
+	}
+
+	l, err := ReadVarInt(zbuf)
+	if err != nil {
+		return
+	}
+
+	if l == 0 {
+		buf = zbuf
+		id, err = ReadVarInt(buf)
+		if err == nil {
+			traceFrame(r, false, id, buf.Bytes(), int(n), 0)
+		}
+		return
+	}
+	if l < 0 || l > MaxUncompressedPacketLength {
+		err = fmt.Errorf("%w: invalid uncompressed packet length: %v", stage.ErrProtocol, l)
+		return
+	}
+
+	buf = bytes.NewBuffer(make([]byte, 0, l))
+	var rc io.ReadCloser
+	rc, err = zlib.NewReader(zbuf)
+	if err != nil {
+		return
+	}
+	// Read one more byte than expected to catch decompression bombs
+	_, err = io.Copy(buf, io.LimitReader(rc, int64(l)+1))
+	if err != nil {
+		return
+	}
+	if buf.Len() != int(l) {
+		err = fmt.Errorf("%w: expected %v uncompressed bytes, got: %v", stage.ErrProtocol, l, buf.Len())
+		return
+	}
+	err = rc.Close()
+	if err != nil {
+		return
+	}
+
+	id, err = ReadVarInt(buf)
+	if err == nil {
+		traceFrame(r, false, id, buf.Bytes(), int(n), int(l))
+	}
+
+	return
+}
+
+// WriteCompressedPacket is like WritePacket, for packets sent after compression is enabled.
+// p is compressed if it is at least threshold bytes long.
+func WriteCompressedPacket(w io.Writer, p []byte, threshold int) error {
+	buf := &bytes.Buffer{}
+	dataLength := 0
+	if len(p) < threshold {
+		err1 := WriteVarInt(buf, 0)
+		_, err2 := buf.Write(p)
+		if err := cmp.Or(err1, err2); err != nil {
+			return err
+		}
+	} else {
+		dataLength = len(p)
+		err1 := WriteVarInt(buf, int32(len(p)))
+		zw := zlib.NewWriter(buf)
+		_, err2 := zw.Write(p)
+		err3 := zw.Close()
+		if err := cmp.Or(err1, err2, err3); err != nil {
+			return err
+		}
+	}
+
+	err := writePacket(w, buf.Bytes())
+	if err == nil {
+		tracePacket(w, p, buf.Len(), dataLength)
+	}
+	return err
+}
+
+// tracePacket traces p, which starts with the packet ID, if w is a TraceConn.
+func tracePacket(w io.Writer, p []byte, length, dataLength int) {
+	r := bytes.NewReader(p)
+	id, err := ReadVarInt(r)
+	if err != nil {
+		return
+	}
+	traceFrame(w, true, id, p[len(p)-r.Len():], length, dataLength)
+}
+
+// ReadPacketLength reads the length prefix of a packet and checks that it is within MaxPacketLength.
+func ReadPacketLength(r io.Reader) (n int32, err error) {
+	n, err = ReadVarInt(r)
+	if err != nil {
+		return
+	}
+	if n < 1 || n > MaxPacketLength {
+		err = fmt.Errorf("%w: invalid packet length: %v", stage.ErrProtocol, n)
+	}
+	return
+}
@@ -0,0 +1,32 @@
+package wire
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzReadPacket(f *testing.F) {
+	buf := &bytes.Buffer{}
+	WritePacket(buf, []byte{0x00, 'h', 'i'})
+	f.Add(buf.Bytes())
+	f.Fuzz(func(t *testing.T, b []byte) {
+		_, buf, err := ReadPacket(bytes.NewReader(b))
+		if err == nil && buf.Len() >= MaxPacketLength {
+			t.Errorf("read %v bytes of data, more than the packet length limit", buf.Len())
+		}
+	})
+}
+
+func FuzzReadCompressedPacket(f *testing.F) {
+	for _, threshold := range []int{0, 256} {
+		buf := &bytes.Buffer{}
+		WriteCompressedPacket(buf, bytes.Repeat([]byte{0x01}, 128), threshold)
+		f.Add(buf.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		_, buf, err := ReadCompressedPacket(bytes.NewReader(b))
+		if err == nil && buf.Len() > MaxUncompressedPacketLength {
+			t.Errorf("read %v bytes of data, more than the uncompressed length limit", buf.Len())
+		}
+	})
+}
@@ -0,0 +1,12 @@
+package wire
+
+// QueryMagic starts every query packet sent by the client.
+const QueryMagic uint16 = 0xFEFD
+
+// QueryPacketType is the type of a query packet, which the server echoes in its response.
+type QueryPacketType byte
+
+const (
+	QueryPacketTypeHandshake QueryPacketType = 9
+	QueryPacketTypeStat      QueryPacketType = 0
+)
@@ -0,0 +1,16 @@
+package wire
+
+// RakNetMagic identifies unconnected RakNet packets.
+var RakNetMagic = [16]byte{0x00, 0xff, 0xff, 0x00, 0xfe, 0xfe, 0xfe, 0xfe, 0xfd, 0xfd, 0xfd, 0xfd, 0x12, 0x34, 0x56, 0x78}
+
+const (
+	RakNetPacketIdUnconnectedPing           byte = 0x01
+	RakNetPacketIdUnconnectedPong           byte = 0x1c
+	RakNetPacketIdOpenConnectionRequest1    byte = 0x05
+	RakNetPacketIdOpenConnectionReply1      byte = 0x06
+	RakNetPacketIdOpenConnectionRequest2    byte = 0x07
+	RakNetPacketIdOpenConnectionReply2      byte = 0x08
+	RakNetPacketIdIncompatibleProtocol      byte = 0x19
+	RakNetPacketIdFrameSet                  byte = 0x84
+	RakNetPacketIdDisconnectionNotification byte = 0x15
+)
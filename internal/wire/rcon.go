@@ -0,0 +1,82 @@
+package wire
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"bhv.sh/minefetch/internal/stage"
+)
+
+// RCON packet types. Login responses and commands share a type.
+const (
+	RconPacketTypeLoginRequest  int32 = 3
+	RconPacketTypeLoginResponse int32 = 2
+	RconPacketTypeCommand       int32 = 2
+	RconPacketTypeMulti         int32 = 0
+	RconPacketTypeFailed        int32 = -1
+)
+
+// Servers split responses into packets with at most 4096 bytes of payload,
+// plus the ID, type and two null bytes.
+const (
+	MaxRconPayloadLength = 4096
+	MaxRconPacketLength  = MaxRconPayloadLength + 10
+)
+
+// WriteRconPacket writes a packet of type t with the request ID id.
+//
+// https://minecraft.wiki/w/RCON#Packet_format
+func WriteRconPacket(w io.Writer, id, t int32, payload string) error {
+	buf1 := &bytes.Buffer{}
+	err1 := binary.Write(buf1, binary.LittleEndian, id)
+	err2 := binary.Write(buf1, binary.LittleEndian, t)
+	_, err3 := buf1.Write([]byte(payload))
+	// Padding seems unnecessary
+	_, err4 := buf1.Write([]byte{0, 0})
+	buf2 := &bytes.Buffer{}
+	err5 := binary.Write(buf2, binary.LittleEndian, int32(buf1.Len()))
+	_, err6 := buf2.Write(buf1.Bytes())
+	_, err7 := w.Write(buf2.Bytes())
+	err := cmp.Or(err1, err2, err3, err4, err5, err6, err7)
+	if err == nil {
+		traceFrame(w, true, t, []byte(payload), buf1.Len(), 0)
+	}
+	return err
+}
+
+// ReadRconPacket reads a single packet written by WriteRconPacket.
+//
+// Multi-packet responses must be reassembled by the caller.
+func ReadRconPacket(r io.Reader) (id int32, t int32, payload string, err error) {
+	var n int32
+	err = binary.Read(r, binary.LittleEndian, &n)
+	if err != nil {
+		return
+	}
+	if n < 9 || n > MaxRconPacketLength {
+		err = fmt.Errorf("%w: invalid packet length: %v", stage.ErrProtocol, n)
+		return
+	}
+
+	b := make([]byte, n)
+	_, err = io.ReadFull(r, b)
+	if err != nil {
+		return
+	}
+
+	buf := bytes.NewBuffer(b)
+	err1 := binary.Read(buf, binary.LittleEndian, &id)
+	err2 := binary.Read(buf, binary.LittleEndian, &t)
+	payload, err3 := buf.ReadString(0)
+	payload = strings.TrimSuffix(payload, "\x00")
+	err = cmp.Or(err1, err2, err3)
+	if err == nil {
+		traceFrame(r, false, t, []byte(payload), int(n), 0)
+	}
+
+	return
+}
@@ -0,0 +1,18 @@
+package wire
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzReadRconPacket(f *testing.F) {
+	buf := &bytes.Buffer{}
+	WriteRconPacket(buf, 1, RconPacketTypeCommand, "list")
+	f.Add(buf.Bytes())
+	f.Fuzz(func(t *testing.T, b []byte) {
+		_, _, payload, err := ReadRconPacket(bytes.NewReader(b))
+		if err == nil && len(payload) > MaxRconPacketLength-9 {
+			t.Errorf("read %v bytes of payload, more than the packet length limit", len(payload))
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package wire
+
+import (
+	"bytes"
+	"net"
+)
+
+// Protocols of a TraceConn.
+const (
+	ProtocolJava   = "java"
+	ProtocolRcon   = "rcon"
+	ProtocolQuery  = "query"
+	ProtocolRakNet = "raknet"
+)
+
+// States of the Java Edition protocol.
+const (
+	StateHandshake     = "handshake"
+	StateStatus        = "status"
+	StateLogin         = "login"
+	StateConfiguration = "configuration"
+)
+
+// Frame is a packet read or written on a TraceConn.
+//
+// For Java Edition and RCON, Frame is decoded by the packet functions of this package:
+// Length is the length prefix, DataLength the uncompressed length of compressed packets,
+// and Data the packet following its ID, decompressed.
+// The ID of RCON packets is their type, and Data their payload.
+// For query and RakNet, Frame is a whole datagram, with Length its length and Data the datagram.
+type Frame struct {
+	Sent       bool
+	Protocol   string
+	State      string
+	ID         int32
+	Name       string
+	Length     int
+	DataLength int
+	Data       []byte
+}
+
+// TraceConn is a connection that calls Trace with each packet read or written.
+//
+// Java Edition and RCON packets are only traced when read or written by the functions of this package,
+// which must be passed the TraceConn itself.
+// The state of Java Edition connections starts at StateHandshake and follows the intent of the handshake,
+// and configuration packets are named after the protocol version of the handshake.
+type TraceConn struct {
+	net.Conn
+	Protocol string
+	Trace    func(f Frame)
+
+	state   string
+	version int32
+}
+
+func (c *TraceConn) Read(b []byte) (n int, err error) {
+	n, err = c.Conn.Read(b)
+	if n > 0 && c.datagrams() {
+		c.traceDatagram(false, b[:n])
+	}
+	return
+}
+
+func (c *TraceConn) Write(b []byte) (n int, err error) {
+	if c.datagrams() {
+		c.traceDatagram(true, b)
+	}
+	return c.Conn.Write(b)
+}
+
+func (c *TraceConn) datagrams() bool {
+	return c.Protocol == ProtocolQuery || c.Protocol == ProtocolRakNet
+}
+
+func (c *TraceConn) traceDatagram(sent bool, b []byte) {
+	f := Frame{Sent: sent, Protocol: c.Protocol, Length: len(b), Data: bytes.Clone(b)}
+	switch {
+	case c.Protocol == ProtocolRakNet:
+		f.ID = int32(b[0])
+	// Requests start with QueryMagic
+	case sent && len(b) >= 3:
+		f.ID = int32(b[2])
+	case !sent:
+		f.ID = int32(b[0])
+	}
+	f.Name = frameName(f)
+	c.Trace(f)
+}
+
+// traceFrame traces a decoded packet if w is a TraceConn.
+func traceFrame(w any, sent bool, id int32, data []byte, length, dataLength int) {
+	c, ok := w.(*TraceConn)
+	if !ok || c.Trace == nil {
+		return
+	}
+	if c.Protocol == ProtocolJava && c.state == "" {
+		c.state = StateHandshake
+	}
+	f := Frame{sent, c.Protocol, c.state, id, "", length, dataLength, bytes.Clone(data)}
+	key := f
+	if c.state == StateConfiguration {
+		// Names are listed by the latest IDs
+		key.ID, _ = LatestConfigurationPacketId(c.version, sent, id)
+	}
+	f.Name = frameName(key)
+	c.Trace(f)
+
+	// Follow the state of the client
+	switch {
+	case c.state == StateHandshake && sent && id == HandshakePacketId && len(data) > 0:
+		// The protocol version is the first field of the handshake, and the intent the last
+		c.version, _ = ReadVarInt(bytes.NewReader(data))
+		switch data[len(data)-1] {
+		case 1:
+			c.state = StateStatus
+		case 2, 3:
+			c.state = StateLogin
+		}
+	case c.state == StateLogin && sent && id == LoginPacketIdLoginAcknowledged:
+		c.state = StateConfiguration
+	}
+}
+
+// frameName returns the name of the packet f, or "" if it is unknown.
+func frameName(f Frame) string {
+	var names map[int32]string
+	switch f.Protocol {
+	case ProtocolJava:
+		names = javaNames[javaKey{f.State, f.Sent}]
+	case ProtocolRcon:
+		if f.Sent {
+			names = rconSentNames
+		} else {
+			names = rconReceivedNames
+		}
+	case ProtocolQuery:
+		names = queryNames
+	case ProtocolRakNet:
+		names = rakNetNames
+	}
+	return names[f.ID]
+}
+
+type javaKey struct {
+	state string
+	sent  bool
+}
+
+var javaNames = map[javaKey]map[int32]string{
+	{StateHandshake, true}: {
+		HandshakePacketId: "Handshake",
+	},
+	{StateStatus, true}: {
+		StatusPacketIdStatusRequest: "Status Request",
+		StatusPacketIdPingRequest:   "Ping Request",
+	},
+	{StateStatus, false}: {
+		StatusPacketIdStatusResponse: "Status Response",
+		StatusPacketIdPongResponse:   "Pong Response",
+	},
+	{StateLogin, true}: {
+		LoginPacketIdLoginStart:          "Login Start",
+		LoginPacketIdEncryptionResponse:  "Encryption Response",
+		LoginPacketIdLoginPluginResponse: "Login Plugin Response",
+		LoginPacketIdLoginAcknowledged:   "Login Acknowledged",
+		LoginPacketIdCookieResponse:      "Cookie Response",
+	},
+	{StateLogin, false}: {
+		LoginPacketIdDisconnect:         "Disconnect",
+		LoginPacketIdEncryptionRequest:  "Encryption Request",
+		LoginPacketIdLoginSuccess:       "Login Success",
+		LoginPacketIdSetCompression:     "Set Compression",
+		LoginPacketIdLoginPluginRequest: "Login Plugin Request",
+		LoginPacketIdLoginCookieRequest: "Cookie Request",
+	},
+	{StateConfiguration, true}: {
+		ConfigurationPacketIdClientInformation:              "Client Information",
+		ConfigurationPacketIdCookieResponse:                 "Cookie Response",
+		ConfigurationPacketIdServerboundPluginMessage:       "Plugin Message",
+		ConfigurationPacketIdAcknowledgeFinishConfiguration: "Acknowledge Finish Configuration",
+		ConfigurationPacketIdServerboundKeepAlive:           "Keep Alive",
+		ConfigurationPacketIdPong:                           "Pong",
+		ConfigurationPacketIdResourcePackResponse:           "Resource Pack Response",
+		ConfigurationPacketIdServerboundKnownPacks:          "Known Packs",
+		ConfigurationPacketIdCustomClickAction:              "Custom Click Action",
+		ConfigurationPacketIdAcceptCodeOfConduct:            "Accept Code of Conduct",
+	},
+	{StateConfiguration, false}: {
+		ConfigurationPacketIdCookieRequest:            "Cookie Request",
+		ConfigurationPacketIdClientboundPluginMessage: "Plugin Message",
+		ConfigurationPacketIdDisconnect:               "Disconnect",
+		ConfigurationPacketIdFinishConfiguration:      "Finish Configuration",
+		ConfigurationPacketIdClientboundKeepAlive:     "Keep Alive",
+		ConfigurationPacketIdPing:                     "Ping",
+		ConfigurationPacketIdResetChat:                "Reset Chat",
+		ConfigurationPacketIdRegistryData:             "Registry Data",
+		ConfigurationPacketIdRemoveResourcePack:       "Remove Resource Pack",
+		ConfigurationPacketIdAddResourcePack:          "Add Resource Pack",
+		ConfigurationPacketIdStoreCookie:              "Store Cookie",
+		ConfigurationPacketIdTransfer:                 "Transfer",
+		ConfigurationPacketIdFeatureFlags:             "Feature Flags",
+		ConfigurationPacketIdUpdateTags:               "Update Tags",
+		ConfigurationPacketIdClientboundKnownPacks:    "Known Packs",
+		ConfigurationPacketIdCustomReportDetails:      "Custom Report Details",
+		ConfigurationPacketIdServerLinks:              "Server Links",
+		ConfigurationPacketIdClearDialog:              "Clear Dialog",
+		ConfigurationPacketIdShowDialog:               "Show Dialog",
+		ConfigurationPacketIdCodeOfConduct:            "Code of Conduct",
+	},
+}
+
+var rconSentNames = map[int32]string{
+	RconPacketTypeLoginRequest: "Login",
+	RconPacketTypeCommand:      "Command",
+}
+
+var rconReceivedNames = map[int32]string{
+	RconPacketTypeLoginResponse: "Login Response",
+	RconPacketTypeMulti:         "Response",
+}
+
+var queryNames = map[int32]string{
+	int32(QueryPacketTypeHandshake): "Handshake",
+	int32(QueryPacketTypeStat):      "Stat",
+}
+
+var rakNetNames = map[int32]string{
+	int32(RakNetPacketIdUnconnectedPing):           "Unconnected Ping",
+	int32(RakNetPacketIdUnconnectedPong):           "Unconnected Pong",
+	int32(RakNetPacketIdOpenConnectionRequest1):    "Open Connection Request 1",
+	int32(RakNetPacketIdOpenConnectionReply1):      "Open Connection Reply 1",
+	int32(RakNetPacketIdOpenConnectionRequest2):    "Open Connection Request 2",
+	int32(RakNetPacketIdOpenConnectionReply2):      "Open Connection Reply 2",
+	int32(RakNetPacketIdIncompatibleProtocol):      "Incompatible Protocol Version",
+	int32(RakNetPacketIdFrameSet):                  "Frame Set",
+	int32(RakNetPacketIdDisconnectionNotification): "Disconnection Notification",
+}
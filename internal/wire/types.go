@@ -0,0 +1,122 @@
+// Package wire encodes the data types and packet framing shared by the Java Edition and Bedrock Edition clients,
+// and the test servers in mctest.
+package wire
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf16"
+
+	"bhv.sh/minefetch/internal/stage"
+)
+
+// Maximum lengths of strings in characters, as enforced by the client.
+const (
+	MaxStringLength = 32767
+	MaxChatLength   = 262144
+)
+
+// ReadString reads a string of at most max characters.
+//
+// https://minecraft.wiki/w/Java_Edition_protocol/Packets#Type:String
+func ReadString(r io.Reader, max int) (s string, err error) {
+	x, err := ReadVarInt(r)
+	if err != nil {
+		return
+	}
+	// A character is at most 3 bytes, as the length is counted in UTF-16 code units
+	if x < 0 || int(x) > max*3 {
+		err = fmt.Errorf("%w: invalid string length: %v", stage.ErrProtocol, x)
+		return
+	}
+
+	buf := make([]byte, x)
+	n, err := io.ReadFull(r, buf)
+	if err != nil {
+		return
+	}
+	if n != int(x) {
+		err = errors.New(fmt.Sprint("expected", x, "bytes but got", n))
+		return
+	}
+
+	s = string(buf)
+	if n := len(utf16.Encode([]rune(s))); n > max {
+		err = fmt.Errorf("%w: string is too long: %v characters", stage.ErrProtocol, n)
+		return
+	}
+
+	return
+}
+
+// WriteString writes s prefixed by its length in bytes.
+func WriteString(w io.Writer, s string) error {
+	err := WriteVarInt(w, int32(len(s)))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write([]byte(s))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// https://minecraft.wiki/w/Java_Edition_protocol/Data_types#VarInt_and_VarLong
+
+const segmentBits byte = 0b0111_1111
+const continueBit byte = 0b1000_0000
+
+// ReadVarInt reads a variable-length integer of at most 5 bytes.
+func ReadVarInt(r io.Reader) (x int32, err error) {
+	x = 0
+	position := 0
+	curr := make([]byte, 1)
+
+	for {
+		var n int
+		n, err = r.Read(curr)
+		if err != nil {
+			return
+		}
+		if n != 1 {
+			err = errors.New(fmt.Sprint("expected 1 byte but read ", n))
+			return
+		}
+
+		x |= int32(curr[0]&segmentBits) << position
+
+		if curr[0]&continueBit == 0 {
+			break
+		}
+
+		position += 7
+
+		if position >= 32 {
+			err = fmt.Errorf("%w: VarInt is too big", stage.ErrProtocol)
+			return
+		}
+	}
+
+	return x, nil
+}
+
+// WriteVarInt writes x in as few bytes as possible.
+func WriteVarInt(w io.Writer, x int32) error {
+	uval := uint32(x)
+	for {
+		if (uval & ^uint32(segmentBits)) == 0 {
+			_, err := w.Write([]byte{byte(uval)})
+			return err
+		}
+
+		_, err := w.Write([]byte{byte(uval&uint32(segmentBits)) | continueBit})
+		if err != nil {
+			return err
+		}
+		uval >>= 7
+	}
+}
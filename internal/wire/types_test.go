@@ -0,0 +1,20 @@
+package wire
+
+import (
+	"bytes"
+	"testing"
+	"unicode/utf16"
+)
+
+func FuzzReadString(f *testing.F) {
+	buf := &bytes.Buffer{}
+	WriteString(buf, "minefetch")
+	f.Add(buf.Bytes(), 16)
+	f.Fuzz(func(t *testing.T, b []byte, max int) {
+		max = min(max&0xfffff, MaxChatLength)
+		s, err := ReadString(bytes.NewReader(b), max)
+		if n := len(utf16.Encode([]rune(s))); err == nil && n > max {
+			t.Errorf("read %v characters, more than %v", n, max)
+		}
+	})
+}
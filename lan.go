@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"bhv.sh/minefetch/internal/term"
+	"bhv.sh/minefetch/mc"
+	"bhv.sh/minefetch/mcpe"
+)
+
+// printLan discovers Java Edition LAN worlds and Bedrock Edition LAN servers,
+// then prints the normal fetch for each one.
+func printLan() {
+	d := max(cfg.timeout, 2*mc.LanInterval)
+
+	var worlds []mc.LanWorld
+	var servers []mcpe.LanServer
+	var javaErr, bedrockErr error
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		worlds, javaErr = mc.DiscoverLan(d)
+	})
+	wg.Go(func() {
+		servers, bedrockErr = mcpe.DiscoverLan(cfg.bedrock.port, d)
+	})
+	wg.Wait()
+
+	if javaErr != nil {
+		log.Println("Failed to listen for Java LAN worlds:", javaErr)
+	}
+	if bedrockErr != nil {
+		log.Println("Failed to broadcast Bedrock ping:", bedrockErr)
+	}
+	if len(worlds) == 0 && len(servers) == 0 {
+		fmt.Println("No LAN servers found.")
+		return
+	}
+
+	base := cfg
+	for _, world := range worlds {
+		cfg = base
+		cfg.host, cfg.port = world.Host, world.Port
+		printLanHeading(mc.LegacyTextAnsi(world.Motd), "Java", mc.JoinHostPort(world.Host, world.Port))
+		results := startProbes()
+		printResults(results)
+		// The probes read cfg, which changes for the next endpoint
+		results.drain()
+	}
+	for _, server := range servers {
+		cfg = base
+		cfg.host, cfg.bedrock.port = server.Addr.IP.String(), uint16(server.Addr.Port)
+		cfg.bedrock.enabled = true
+		cfg.status, cfg.query.enabled, cfg.cracked, cfg.rcon.enabled = false, false, false, false
+		printLanHeading(mcpe.LegacyTextAnsi(server.Status.Name), "Bedrock", server.Addr.String())
+		results := startProbes()
+		printResults(results)
+		// The probes read cfg, which changes for the next endpoint
+		results.drain()
+	}
+}
+
+func printLanHeading(name, edition, address string) {
+	fmt.Println(term.Bold + name + term.Reset + term.Gray + " (" + edition + ", " + address + ")" + term.Reset)
+}
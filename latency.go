@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// latencyStats summarizes ping round-trip times in the style of ping(8).
+//
+// mdev is the standard deviation.
+// jitter is the mean difference between consecutive round-trip times.
+type latencyStats struct {
+	sent, received      int
+	min, avg, max, mdev time.Duration
+	jitter              time.Duration
+}
+
+// newLatencyStats computes statistics for latencies, where lost pings are 0.
+func newLatencyStats(latencies []time.Duration) (stats latencyStats) {
+	stats.sent = len(latencies)
+	var sum, sumSq float64
+	var prev time.Duration
+	var diffs time.Duration
+	for _, l := range latencies {
+		if l == 0 {
+			continue
+		}
+		if stats.received == 0 || l < stats.min {
+			stats.min = l
+		}
+		stats.max = max(stats.max, l)
+		if stats.received != 0 {
+			diffs += (l - prev).Abs()
+		}
+		prev = l
+		stats.received++
+		sum += float64(l)
+		sumSq += float64(l) * float64(l)
+	}
+	if stats.received == 0 {
+		return
+	}
+	n := float64(stats.received)
+	mean := sum / n
+	stats.avg = time.Duration(mean)
+	stats.mdev = time.Duration(math.Sqrt(max(sumSq/n-mean*mean, 0)))
+	if stats.received > 1 {
+		stats.jitter = diffs / time.Duration(stats.received-1)
+	}
+	return
+}
+
+func (stats latencyStats) loss() float64 {
+	return float64(stats.sent-stats.received) / float64(stats.sent) * 100
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"bhv.sh/minefetch/internal/term"
+)
+
+var spinner = [...]string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+const spinnerInterval = 100 * time.Millisecond
+
+// liveSupported reports whether the output can be redrawn in place as probes finish.
+// Packets traced to stderr would be overwritten by the redraw.
+func liveSupported() bool {
+	return cfg.output == "print" && !cfg.trace && term.ColorSupport != term.NoColorSupport && term.IsTerminal()
+}
+
+func printResults(results *results) {
+	if liveSupported() {
+		printLive(results)
+		return
+	}
+	results.wait()
+	newRenderer().render(newReport(results))
+}
+
+// printLive prints the report as soon as the first results arrive,
+// and redraws the fields in place each time another probe finishes.
+// Fields of pending probes are shown with a spinner.
+//
+// If the fields grow taller than the terminal, they can no longer be redrawn,
+// so the report is printed normally once all probes are done.
+func printLive(results *results) {
+	l := &liveRenderer{}
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+
+	r := newReport(results)
+	finished := false
+	for {
+		if !l.draw(r) {
+			fmt.Print(term.ClearDown())
+			results.wait()
+			(&terminalRenderer{}).render(newReport(results))
+			return
+		}
+		if finished {
+			l.finish(r)
+			return
+		}
+		select {
+		case <-results.finished:
+			finished = true
+			r = newReport(results)
+		case <-results.changed:
+			r = newReport(results)
+		case <-ticker.C:
+			l.frame++
+		}
+	}
+}
+
+// liveRenderer draws fields in place, starting at the same line each time.
+//
+// lines is the number of lines drawn last time.
+type liveRenderer struct {
+	lines     int
+	frame     int
+	iconDrawn bool
+}
+
+// draw draws the icon once it is known, and the fields of r next to it,
+// then moves the cursor back to where drawing started.
+// It reports false without drawing if the fields do not fit in the terminal.
+func (l *liveRenderer) draw(r *report) bool {
+	var prefix string
+	if cfg.icon.enabled {
+		prefix = term.Fwd(cfg.icon.size + padding)
+	}
+
+	var ss []string
+	for _, s := range r.sections {
+		for _, f := range s.fields {
+			ss = append(ss, l.fieldLines(f)...)
+		}
+	}
+	n := max(len(ss), l.lines)
+	if _, height, err := term.Size(); err == nil && height > 0 && uint(n) >= height {
+		return false
+	}
+
+	if cfg.icon.enabled && !l.iconDrawn && !r.iconPending {
+		fmt.Print("\r")
+		printIcon(r.icon)
+		l.iconDrawn = true
+	}
+
+	var b strings.Builder
+	for _, s := range ss {
+		b.WriteString("\r" + prefix + term.ClearLine() + s + term.Reset + "\n")
+	}
+	// Clear lines left over from the previous draw
+	for range l.lines - len(ss) {
+		b.WriteString("\r" + prefix + term.ClearLine() + "\n")
+	}
+	if n > 0 {
+		b.WriteString(term.Up(uint(n)))
+	}
+	fmt.Print(b.String())
+	l.lines = len(ss)
+	return true
+}
+
+// fieldLines returns the lines of f as drawn, without the icon offset.
+func (l *liveRenderer) fieldLines(f field) []string {
+	label := term.Bold + term.Blue + f.label + term.Reset + ": "
+	if f.pending {
+		return []string{label + term.Gray + spinner[l.frame%len(spinner)]}
+	}
+	ss := fieldLines(f)
+	ss[0] = label + ss[0]
+	for i := 1; i < len(ss); i++ {
+		ss[i] = strings.Repeat(" ", len(f.label)+2) + ss[i]
+	}
+	return ss
+}
+
+// finish moves the cursor below the last drawn fields, and prints the palette and padding.
+func (l *liveRenderer) finish(r *report) {
+	if l.lines > 0 {
+		fmt.Print(term.Down(uint(l.lines)))
+	}
+	t := &terminalRenderer{lines: l.lines}
+	if cfg.palette {
+		t.printPalette(r.bedrock)
+	}
+	if cfg.icon.enabled && t.lines < int(iconHeight())+1 {
+		fmt.Print(strings.Repeat("\n", int(iconHeight())-t.lines+1))
+	} else {
+		fmt.Print("\n")
+	}
+}
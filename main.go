@@ -14,12 +14,39 @@ func main() {
 		log.Fatalf("Failed to parse arguments: %v\nSee minefetch --help\n", err)
 	}
 
-	results := getResults()
+	if cfg.lan {
+		printLan()
+		return
+	}
+
+	if cfg.diagnose {
+		printDiagnosis()
+		return
+	}
+
+	var results *results
+	if offline() {
+		results, err = loadResults()
+		if err != nil {
+			log.Fatalln("Failed to read response:", err)
+		}
+	} else {
+		results = startProbes()
+	}
 
 	switch cfg.output {
-	case "print":
-		printResults(results)
 	case "raw":
+		results.wait()
 		printRawResults(results)
+	default:
+		printResults(results)
+	}
+
+	if cfg.record != "" {
+		results.wait()
+		err = session.save(cfg.record)
+		if err != nil {
+			log.Fatalln("Failed to save recording:", err)
+		}
 	}
 }
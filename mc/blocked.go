@@ -1,6 +1,7 @@
 package mc
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"errors"
@@ -11,10 +12,17 @@ import (
 
 // IsBlocked reports whether host is listed in Mojang's [blocked servers list].
 //
+// The SRV record of host is looked up with the Client's Resolver,
+// but the list itself is fetched with http.DefaultClient.
+//
 // [blocked servers list]: https://github.com/sudofox/mojang-blocklist
-func IsBlocked(host string) (selector string, err error) {
-	host, _ = lookupHostPort(host, 25565)
-	resp, err := http.Get("https://sessionserver.mojang.com/blockedservers")
+func (c *Client) IsBlocked(ctx context.Context, host string) (selector string, err error) {
+	host, _ = c.lookupHostPort(ctx, host, 25565)
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://sessionserver.mojang.com/blockedservers", nil)
+	if err != nil {
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return
 	}
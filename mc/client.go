@@ -0,0 +1,234 @@
+// Package mc implements a client for a subset of the Java Edition protocol.
+//
+// Requests are made with a [Client], whose zero value is ready to use.
+package mc
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"time"
+
+	"bhv.sh/minefetch/internal/netctx"
+	"bhv.sh/minefetch/internal/wire"
+	"bhv.sh/minefetch/mcpe"
+)
+
+// Dialer connects to servers. It is implemented by *net.Dialer.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// Resolver looks up SRV records and host addresses. It is implemented by *net.Resolver.
+type Resolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Client sends requests to Java Edition and Bedrock Edition servers.
+//
+// The zero value is ready to use.
+// A Client may be used concurrently, but its fields must not be modified while requests are running.
+type Client struct {
+	// Dialer connects to servers.
+	// If nil, a net.Dialer using LocalAddr is used.
+	Dialer Dialer
+
+	// Resolver looks up the Minecraft SRV record of domains.
+	// If set, it also resolves domains before dialing,
+	// otherwise they are passed to Dialer as is.
+	// If nil, net.DefaultResolver is used for SRV lookups.
+	Resolver Resolver
+
+	// Timeout limits each exchange with the server, such as the status request or a single ping.
+	// A Timeout of 0 means no timeout, although the context may have a deadline.
+	Timeout time.Duration
+
+	// Protocol is the protocol version number sent in Java Edition handshakes.
+	// If 0, the latest stable release known at build time is used.
+	Protocol int32
+
+	// HandshakeHost and HandshakePort override the server address sent in Java Edition handshakes,
+	// which is otherwise the address connected to after SRV lookup.
+	// Proxies and virtual hosts use it to select a backend.
+	HandshakeHost string
+	HandshakePort uint16
+
+	// LocalAddr is the local IP address to connect from if Dialer is nil.
+	LocalAddr netip.Addr
+
+	// Proxy is the PROXY protocol header sent with Status, Login, RCON and Query requests.
+	Proxy ProxyHeader
+
+	// Retry controls how Query and Bedrock requests, which are sent over UDP, are resent.
+	// By default, they are not.
+	Retry Retry
+
+	// Trace, if set, is called with each packet sent and received.
+	// It is called from the goroutine making the request, and must not retain the packet data.
+	Trace func(p TracePacket)
+}
+
+// Retry controls how often an unanswered UDP request is resent, see [mcpe.Retry].
+type Retry = mcpe.Retry
+
+// TracePacket is a packet passed to Client.Trace.
+//
+// Protocol is "java", "rcon", "query" or "raknet", and State the Java Edition protocol state,
+// such as "status" or "login". Name is the name of the packet ID, or "" if it is unknown.
+//
+// For Java Edition, Length is the length prefix of the packet, and Data the packet following its ID.
+// DataLength is the uncompressed length of compressed packets, or 0, in which case Data is decompressed.
+// For RCON, ID is the packet type and Data the payload.
+// For query and RakNet, ID is the packet type, and Data and Length are the whole datagram.
+type TracePacket = wire.Frame
+
+// traced returns conn wrapped to trace the packets of protocol if Trace is set.
+func (c *Client) traced(conn net.Conn, protocol string) net.Conn {
+	if c.Trace == nil {
+		return conn
+	}
+	return &wire.TraceConn{Conn: conn, Protocol: protocol, Trace: c.Trace}
+}
+
+func (c *Client) dialer() Dialer {
+	if c.Dialer != nil {
+		return c.Dialer
+	}
+	return &localDialer{c.LocalAddr}
+}
+
+func (c *Client) resolver() Resolver {
+	if c.Resolver != nil {
+		return c.Resolver
+	}
+	return net.DefaultResolver
+}
+
+func (c *Client) protocol() int32 {
+	if c.Protocol != 0 {
+		return c.Protocol
+	}
+	return VersionNameId["latest"]
+}
+
+// handshakeAddr returns the address to send in handshakes when connecting to host and port.
+func (c *Client) handshakeAddr(host string, port uint16) (string, uint16) {
+	return cmp.Or(c.HandshakeHost, host), cmp.Or(c.HandshakePort, port)
+}
+
+// deadline returns the deadline of an exchange starting now,
+// which is the earliest of ctx's deadline and Timeout from now.
+// It returns the zero time if there is neither.
+func (c *Client) deadline(ctx context.Context) time.Time {
+	return netctx.Deadline(ctx, c.Timeout)
+}
+
+// dial connects to address, and writes the PROXY protocol header if one is configured.
+//
+// Connecting is limited by Timeout.
+// If Resolver is set, domains are resolved with it and each address is tried in order.
+// The connection is closed when ctx is done.
+func (c *Client) dial(ctx context.Context, network, address string) (conn net.Conn, err error) {
+	dialCtx := ctx
+	if t := c.deadline(ctx); !t.IsZero() {
+		var cancel context.CancelFunc
+		dialCtx, cancel = context.WithDeadline(ctx, t)
+		defer cancel()
+	}
+	conn, err = c.dialResolved(dialCtx, network, address)
+	if err != nil {
+		return
+	}
+	conn, err = c.Proxy.wrap(network, netctx.CloseOnDone(ctx, conn))
+	return
+}
+
+func (c *Client) dialResolved(ctx context.Context, network, address string) (conn net.Conn, err error) {
+	host, port, err := net.SplitHostPort(address)
+	if c.Resolver == nil || err != nil || net.ParseIP(host) != nil {
+		return c.dialer().DialContext(ctx, network, address)
+	}
+	addrs, err := c.Resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return
+	}
+	if len(addrs) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	for _, addr := range addrs {
+		conn, err = c.dialer().DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+		if err == nil {
+			return
+		}
+	}
+	return
+}
+
+// localDialer is a net.Dialer bound to a local IP address.
+type localDialer struct {
+	ip netip.Addr
+}
+
+func (d *localDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	var dialer net.Dialer
+	if d.ip.IsValid() {
+		switch network {
+		case "tcp", "tcp4", "tcp6":
+			dialer.LocalAddr = net.TCPAddrFromAddrPort(netip.AddrPortFrom(d.ip, 0))
+		case "udp", "udp4", "udp6":
+			dialer.LocalAddr = net.UDPAddrFromAddrPort(netip.AddrPortFrom(d.ip, 0))
+		default:
+			return nil, errors.New("unsupported network: " + network)
+		}
+	}
+	return dialer.DialContext(ctx, network, address)
+}
+
+// Bedrock attempts to get general Bedrock Edition server info, see mcpe.Status.
+//
+// The PROXY protocol header is not sent, and HandshakeHost and HandshakePort are not used.
+// The port defaults to 19132.
+func (c *Client) Bedrock(ctx context.Context, address string) (mcpe.StatusResponse, error) {
+	return c.BedrockPing(ctx, address, 1, 0)
+}
+
+// BedrockPing is like Bedrock, but sends count pings interval apart, see mcpe.StatusPing.
+func (c *Client) BedrockPing(ctx context.Context, address string, count int, interval time.Duration) (mcpe.StatusResponse, error) {
+	return mcpe.StatusPing(ctx, c.bedrockDialer(), c.bedrockAddress(address), count, interval, c.Timeout, c.Retry)
+}
+
+// BedrockProbe performs the RakNet connection handshake with a Bedrock Edition server,
+// see mcpe.Probe.
+func (c *Client) BedrockProbe(ctx context.Context, address string, ports []uint16) (mcpe.ProbeResponse, error) {
+	return mcpe.Probe(ctx, c.bedrockDialer(), c.bedrockAddress(address), ports, c.Timeout)
+}
+
+// DiagnoseBedrock runs the same steps as Bedrock one at a time, see mcpe.Diagnose.
+func (c *Client) DiagnoseBedrock(ctx context.Context, address string) []StepResult {
+	return mcpe.Diagnose(ctx, c.bedrockDialer(), c.resolver(), c.bedrockAddress(address), c.Timeout)
+}
+
+func (c *Client) bedrockAddress(address string) string {
+	host, port, _ := splitHostPort(address, 19132)
+	return JoinHostPort(host, port)
+}
+
+// bedrockDialer resolves domains with Resolver if it is set.
+func (c *Client) bedrockDialer() mcpe.Dialer {
+	return dialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := c.dialResolved(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		return c.traced(conn, wire.ProtocolRakNet), nil
+	})
+}
+
+type dialerFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+func (f dialerFunc) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return f(ctx, network, address)
+}
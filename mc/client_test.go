@@ -0,0 +1,283 @@
+package mc_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"bhv.sh/minefetch/internal/wire"
+	"bhv.sh/minefetch/mc"
+	"bhv.sh/minefetch/mc/mctest"
+)
+
+const status = `{"version":{"name":"Paper 1.21.4","protocol":769},"players":{"max":20,"online":1},"description":"A Minecraft Server"}`
+
+func newClient() *mc.Client {
+	return &mc.Client{Timeout: time.Second}
+}
+
+// start starts s and closes it at the end of the test.
+func start(t *testing.T, s interface {
+	Start() error
+	Close() error
+}) {
+	t.Helper()
+	err := s.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+}
+
+func TestStatus(t *testing.T) {
+	s := &mctest.JavaServer{Status: status}
+	start(t, s)
+
+	got, err := newClient().Status(context.Background(), s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version.Name != "Paper 1.21.4" || got.Version.Protocol != 769 {
+		t.Errorf("Version = %+v, want Paper 1.21.4 (769)", got.Version)
+	}
+	if got.Players.Online != 1 || got.Players.Max != 20 {
+		t.Errorf("Players = %v/%v, want 1/20", got.Players.Online, got.Players.Max)
+	}
+	if got.Motd.Raw() != "A Minecraft Server" {
+		t.Errorf("Motd = %q, want %q", got.Motd.Raw(), "A Minecraft Server")
+	}
+	if got.Latency == 0 || got.PingErr != nil {
+		t.Errorf("Latency = %v, PingErr = %v, want a latency", got.Latency, got.PingErr)
+	}
+
+	requests := s.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("got %v connections, want 1", len(requests))
+	}
+	if hs := requests[0].Handshake; hs.Intent != 1 || hs.Host != "127.0.0.1" {
+		t.Errorf("Handshake = %+v, want a status handshake to 127.0.0.1", hs)
+	}
+}
+
+func TestStatusPing(t *testing.T) {
+	s := &mctest.JavaServer{Status: status}
+	start(t, s)
+
+	got, err := newClient().StatusPing(context.Background(), s.Addr(), 3, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Latencies) != 3 || slices.Contains(got.Latencies, 0) {
+		t.Errorf("Latencies = %v, want 3 received pings", got.Latencies)
+	}
+	if got.Latency == 0 {
+		t.Error("Latency = 0, want the average of the pings")
+	}
+}
+
+func TestLoginConfiguration(t *testing.T) {
+	brand := &bytes.Buffer{}
+	wire.WriteString(brand, "minecraft:brand")
+	wire.WriteString(brand, "Paper")
+	flags := &bytes.Buffer{}
+	wire.WriteVarInt(flags, 1)
+	wire.WriteString(flags, "minecraft:vanilla")
+	s := &mctest.JavaServer{
+		Compression:          true,
+		CompressionThreshold: 16,
+		Configuration: []mctest.Packet{
+			{ID: wire.ConfigurationPacketIdClientboundPluginMessage, Data: brand.Bytes()},
+			{ID: wire.ConfigurationPacketIdFeatureFlags, Data: flags.Bytes()},
+		},
+	}
+	start(t, s)
+
+	got, err := newClient().Login(context.Background(), s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Cracked || got.Whitelisted {
+		t.Errorf("Cracked = %v, Whitelisted = %v, want cracked without whitelist", got.Cracked, got.Whitelisted)
+	}
+	if got.Compression != 16 {
+		t.Errorf("Compression = %v, want 16", got.Compression)
+	}
+	if got.Config == nil {
+		t.Fatal("Config = nil, want the configuration phase")
+	}
+	if got.Config.Err != nil {
+		t.Errorf("Config.Err = %v", got.Config.Err)
+	}
+	if got.Config.Brand != "Paper" {
+		t.Errorf("Config.Brand = %q, want Paper", got.Config.Brand)
+	}
+	if !slices.Equal(got.Config.FeatureFlags, []string{"minecraft:vanilla"}) {
+		t.Errorf("Config.FeatureFlags = %q, want [minecraft:vanilla]", got.Config.FeatureFlags)
+	}
+}
+
+func TestLoginCompressionOff(t *testing.T) {
+	brand := &bytes.Buffer{}
+	wire.WriteString(brand, "minecraft:brand")
+	wire.WriteString(brand, "Paper")
+	s := &mctest.JavaServer{
+		Compression:          true,
+		CompressionThreshold: -1,
+		Configuration:        []mctest.Packet{{ID: wire.ConfigurationPacketIdClientboundPluginMessage, Data: brand.Bytes()}},
+	}
+	start(t, s)
+
+	got, err := newClient().Login(context.Background(), s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Cracked || got.Compression != -1 {
+		t.Errorf("Cracked = %v, Compression = %v, want cracked without compression", got.Cracked, got.Compression)
+	}
+	if got.Config == nil || got.Config.Err != nil || got.Config.Brand != "Paper" {
+		t.Errorf("Config = %+v, want the uncompressed configuration phase", got.Config)
+	}
+}
+
+func TestLoginWhitelist(t *testing.T) {
+	s := &mctest.JavaServer{Disconnect: `{"translate":"multiplayer.disconnect.not_whitelisted"}`}
+	start(t, s)
+
+	got, err := newClient().Login(context.Background(), s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Cracked || !got.Whitelisted || got.Config != nil {
+		t.Errorf("got %+v, want cracked and whitelisted", got)
+	}
+}
+
+func TestLoginEncryption(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &mctest.JavaServer{PublicKey: der}
+	start(t, s)
+
+	got, err := newClient().Login(context.Background(), s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cracked || got.Compression != -1 {
+		t.Errorf("Cracked = %v, Compression = %v, want online mode without compression", got.Cracked, got.Compression)
+	}
+	e := got.Encryption
+	if e == nil {
+		t.Fatal("Encryption = nil, want the Encryption Request")
+	}
+	sum := sha256.Sum256(der)
+	if e.KeySize != 1024 || e.Fingerprint != hex.EncodeToString(sum[:]) || !bytes.Equal(e.PublicKey, der) {
+		t.Errorf("got a %v bit key with fingerprint %v, want the server key", e.KeySize, e.Fingerprint)
+	}
+	if !e.Authenticate {
+		t.Error("Authenticate = false, want true")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	for _, drop := range []int{0, 2} {
+		s := &mctest.QueryServer{
+			Values: [][2]string{
+				{"hostname", "A Minecraft Server"},
+				{"version", "1.21.4"},
+				{"numplayers", "2"},
+				{"maxplayers", "20"},
+			},
+			Players: []string{"Notch", "jeb_"},
+			Token:   1234,
+			Drop:    drop,
+		}
+		start(t, s)
+
+		c := newClient()
+		c.Retry = mc.Retry{Count: drop, Backoff: 20 * time.Millisecond}
+		got, err := c.Query(context.Background(), s.Addr())
+		if err != nil {
+			t.Fatalf("%v dropped: %v", drop, err)
+		}
+		if got.Motd != "A Minecraft Server" || got.Version != "1.21.4" {
+			t.Errorf("%v dropped: got %q (%v), want A Minecraft Server (1.21.4)", drop, got.Motd, got.Version)
+		}
+		if got.Players.Online != 2 || got.Players.Max != 20 || !slices.Equal(got.Players.Sample, s.Players) {
+			t.Errorf("%v dropped: Players = %+v, want 2/20 Notch and jeb_", drop, got.Players)
+		}
+		if n := len(s.Requests()); n != 2 {
+			t.Errorf("%v dropped: got %v answered requests, want 2", drop, n)
+		}
+	}
+}
+
+func TestQueryDroppedWithoutRetry(t *testing.T) {
+	s := &mctest.QueryServer{Token: 1234, Drop: 1}
+	start(t, s)
+
+	c := newClient()
+	c.Timeout = 100 * time.Millisecond
+	_, err := c.Query(context.Background(), s.Addr())
+	var e *mc.Error
+	if !errors.As(err, &e) || e.Kind != mc.KindTimeout || e.Stage != mc.StepQueryHandshake {
+		t.Errorf("err = %v, want a query handshake timeout", err)
+	}
+}
+
+func TestRCON(t *testing.T) {
+	s := &mctest.RCONServer{Password: "secret"}
+	start(t, s)
+
+	enabled, err := newClient().RCON(context.Background(), s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !enabled {
+		t.Error("enabled = false, want true")
+	}
+	packets := s.Packets()
+	if len(packets) != 1 || packets[0].Type != wire.RconPacketTypeLoginRequest || packets[0].Payload != "" {
+		t.Errorf("got %+v, want a single login with an empty password", packets)
+	}
+}
+
+func TestBedrock(t *testing.T) {
+	for _, drop := range []int{0, 1} {
+		s := &mctest.BedrockServer{
+			Pong: "MCPE;Dedicated Server;766;1.21.50;3;10;1234;Bedrock level;Survival;1;19132;19133;",
+			GUID: 1234,
+			Drop: drop,
+		}
+		start(t, s)
+
+		c := newClient()
+		c.Retry = mc.Retry{Count: drop, Backoff: 20 * time.Millisecond}
+		got, err := c.Bedrock(context.Background(), s.Addr())
+		if err != nil {
+			t.Fatalf("%v dropped: %v", drop, err)
+		}
+		if got.Name != "Dedicated Server" || got.Version.Name != "1.21.50" || got.Version.Protocol != 766 {
+			t.Errorf("%v dropped: got %q (%v, %v), want Dedicated Server (1.21.50, 766)", drop, got.Name, got.Version.Name, got.Version.Protocol)
+		}
+		if got.Players.Online != 3 || got.Players.Max != 10 || got.GUID != 1234 || got.Port.IPv4 != 19132 {
+			t.Errorf("%v dropped: got %+v", drop, got)
+		}
+		if n := len(s.Pings()); n != 1 {
+			t.Errorf("%v dropped: got %v answered pings, want 1", drop, n)
+		}
+	}
+}
@@ -0,0 +1,391 @@
+package mc
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"bhv.sh/minefetch/internal/stage"
+	"bhv.sh/minefetch/internal/wire"
+)
+
+// Protocol versions that changed the configuration phase.
+const (
+	configProtocol     = 764 // 1.20.2 added the configuration phase
+	nbtTextProtocol    = 765 // 1.20.3 sends text components as NBT, and resource packs with a UUID
+	knownPacksProtocol = 766 // 1.20.5 sends registries one at a time, and the known packs
+)
+
+// ConfigResponse contains what a server sent during the [configuration phase],
+// which follows a successful login since 1.20.2.
+//
+// Brand is the server software name sent on the minecraft:brand plugin channel.
+// KnownPacks are the data packs the server offered to not send the contents of, since 1.20.5.
+// Registries maps each synchronized registry to the IDs of its entries, such as "minecraft:plains"
+// in "minecraft:worldgen/biome".
+// ServerLinks and ReportDetails are sent since 1.21,
+// and CodeOfConduct is the text players are asked to accept since 1.21.9.
+// ResourcePacks are answered as if they were downloaded and loaded, so that the rest of the phase is sent.
+//
+// Err is the error that ended the phase early, if any, in which case the other fields hold what was received before.
+// As the code of conduct is never accepted, it also ends the phase, but without an error.
+//
+// [configuration phase]: https://minecraft.wiki/w/Java_Edition_protocol/FAQ#What's_the_normal_login_sequence_for_a_client?
+type ConfigResponse struct {
+	Brand         string
+	FeatureFlags  []string
+	KnownPacks    []KnownPack
+	Registries    map[string][]string
+	ServerLinks   []ServerLink
+	ReportDetails []ReportDetail
+	ResourcePacks []ResourcePack
+	CodeOfConduct string
+	Err           error
+}
+
+// KnownPack is a data pack identified by its namespace, ID and version, such as minecraft:core.
+type KnownPack struct {
+	Namespace string
+	ID        string
+	Version   string
+}
+
+// ServerLink is a link shown in the pause menu.
+// The label of built-in links is their English name, such as "Bug Report".
+type ServerLink struct {
+	Label Text
+	URL   string
+}
+
+// ReportDetail is a detail included in crash reports and the bug report link.
+type ReportDetail struct {
+	Title       string
+	Description string
+}
+
+// https://minecraft.wiki/w/Java_Edition_protocol/Packets#Server_Links
+var serverLinkLabels = [...]string{
+	"Bug Report",
+	"Community Guidelines",
+	"Support",
+	"Status",
+	"Feedback",
+	"Community",
+	"Website",
+	"Forums",
+	"News",
+	"Announcements",
+}
+
+// configure acknowledges the login and reads the configuration phase until Finish Configuration,
+// answering what the server waits for.
+// Packets are compressed if threshold is not negative,
+// and their IDs are translated from and to those of protocol.
+//
+// The connection should be closed afterwards, which the server sees as the client disconnecting before play.
+func configure(conn io.ReadWriter, threshold int, protocol int32) (config ConfigResponse) {
+	config.Registries = map[string][]string{}
+	writeRaw := func(id int32, data []byte) error {
+		buf := &bytes.Buffer{}
+		err1 := wire.WriteVarInt(buf, id)
+		_, err2 := buf.Write(data)
+		if err := cmp.Or(err1, err2); err != nil {
+			return err
+		}
+		if threshold < 0 {
+			return wire.WritePacket(conn, buf.Bytes())
+		}
+		return wire.WriteCompressedPacket(conn, buf.Bytes(), threshold)
+	}
+	write := func(id int32, data []byte) error {
+		id, ok := wire.ConfigurationPacketId(protocol, true, id)
+		if !ok {
+			return fmt.Errorf("no packet ID for protocol version %v", protocol)
+		}
+		return writeRaw(id, data)
+	}
+
+	err := writeRaw(wire.LoginPacketIdLoginAcknowledged, nil)
+	for err == nil {
+		var id int32
+		var buf *bytes.Buffer
+		if threshold < 0 {
+			id, buf, err = wire.ReadPacket(conn)
+		} else {
+			id, buf, err = wire.ReadCompressedPacket(conn)
+		}
+		if err != nil {
+			break
+		}
+		id, _ = wire.LatestConfigurationPacketId(protocol, false, id)
+
+		switch id {
+		case wire.ConfigurationPacketIdFinishConfiguration:
+			return
+		case wire.ConfigurationPacketIdCodeOfConduct:
+			config.CodeOfConduct, err = wire.ReadString(buf, wire.MaxStringLength)
+			return
+		case wire.ConfigurationPacketIdDisconnect:
+			var t Text
+			t, err = readText(buf, protocol)
+			if err == nil {
+				err = fmt.Errorf("disconnected: %v", LegacyTextPlain(t.Raw()))
+			}
+		case wire.ConfigurationPacketIdCookieRequest:
+			// Answer that there is no cookie
+			var key string
+			key, err = wire.ReadString(buf, wire.MaxStringLength)
+			if err == nil {
+				b := &bytes.Buffer{}
+				err1 := wire.WriteString(b, key)
+				err2 := b.WriteByte(0)
+				err = cmp.Or(err1, err2, write(wire.ConfigurationPacketIdCookieResponse, b.Bytes()))
+			}
+		case wire.ConfigurationPacketIdClientboundKeepAlive:
+			err = write(wire.ConfigurationPacketIdServerboundKeepAlive, buf.Bytes())
+		case wire.ConfigurationPacketIdPing:
+			err = write(wire.ConfigurationPacketIdPong, buf.Bytes())
+		case wire.ConfigurationPacketIdClientboundKnownPacks:
+			// Claim to know all packs, so that their registry entries are sent without data
+			b := bytes.Clone(buf.Bytes())
+			config.KnownPacks, err = readKnownPacks(buf)
+			if err == nil {
+				err = write(wire.ConfigurationPacketIdServerboundKnownPacks, b)
+			}
+		case wire.ConfigurationPacketIdClientboundPluginMessage:
+			var channel string
+			channel, err = wire.ReadString(buf, wire.MaxStringLength)
+			if err == nil && channel == "minecraft:brand" {
+				config.Brand, err = wire.ReadString(buf, wire.MaxStringLength)
+			}
+		case wire.ConfigurationPacketIdFeatureFlags:
+			config.FeatureFlags, err = readIdentifiers(buf)
+		case wire.ConfigurationPacketIdRegistryData:
+			if protocol < knownPacksProtocol {
+				err = readRegistryCodec(buf, config.Registries)
+			} else {
+				err = readRegistryData(buf, config.Registries)
+			}
+		case wire.ConfigurationPacketIdServerLinks:
+			config.ServerLinks, err = readServerLinks(buf)
+		case wire.ConfigurationPacketIdCustomReportDetails:
+			config.ReportDetails, err = readReportDetails(buf)
+		case wire.ConfigurationPacketIdAddResourcePack:
+			var pack ResourcePack
+			pack, err = readAddResourcePack(buf, protocol)
+			if err != nil {
+				break
+			}
+			config.ResourcePacks = append(config.ResourcePacks, pack)
+			// Answer as the client does once the pack is loaded
+			results := []int32{resourcePackAccepted, resourcePackDownloaded, resourcePackLoaded}
+			if protocol < nbtTextProtocol {
+				// Packs had no UUID, nor a downloaded result
+				results = []int32{resourcePackAccepted, resourcePackLoaded}
+			}
+			for _, result := range results {
+				b := &bytes.Buffer{}
+				if protocol >= nbtTextProtocol {
+					b.Write(pack.UUID[:])
+				}
+				err = cmp.Or(wire.WriteVarInt(b, result), write(wire.ConfigurationPacketIdResourcePackResponse, b.Bytes()))
+				if err != nil {
+					break
+				}
+			}
+		}
+	}
+	config.Err = stage.Wrap(stage.Configuration, err)
+	return
+}
+
+// readCount reads the VarInt length of an array, which must fit in buf with each element taking at least a byte.
+func readCount(buf *bytes.Buffer) (n int, err error) {
+	i, err := wire.ReadVarInt(buf)
+	if err != nil {
+		return
+	}
+	if i < 0 || int(i) > buf.Len() {
+		return 0, fmt.Errorf("%w: invalid array length: %v", stage.ErrProtocol, i)
+	}
+	return int(i), nil
+}
+
+// https://minecraft.wiki/w/Java_Edition_protocol/Packets#Feature_Flags
+func readIdentifiers(buf *bytes.Buffer) (ids []string, err error) {
+	n, err := readCount(buf)
+	if err != nil {
+		return
+	}
+	for range n {
+		var id string
+		id, err = wire.ReadString(buf, wire.MaxStringLength)
+		if err != nil {
+			return
+		}
+		ids = append(ids, id)
+	}
+	return
+}
+
+// https://minecraft.wiki/w/Java_Edition_protocol/Packets#Clientbound_Known_Packs
+func readKnownPacks(buf *bytes.Buffer) (packs []KnownPack, err error) {
+	n, err := readCount(buf)
+	if err != nil {
+		return
+	}
+	for range n {
+		var p KnownPack
+		p.Namespace, err = wire.ReadString(buf, wire.MaxStringLength)
+		if err != nil {
+			return
+		}
+		p.ID, err = wire.ReadString(buf, wire.MaxStringLength)
+		if err != nil {
+			return
+		}
+		p.Version, err = wire.ReadString(buf, wire.MaxStringLength)
+		if err != nil {
+			return
+		}
+		packs = append(packs, p)
+	}
+	return
+}
+
+// readRegistryData adds the entry IDs of a registry to registries.
+// The entry data is read to reach the next entry, but not kept.
+//
+// https://minecraft.wiki/w/Java_Edition_protocol/Packets#Registry_Data_2
+func readRegistryData(buf *bytes.Buffer, registries map[string][]string) (err error) {
+	registry, err := wire.ReadString(buf, wire.MaxStringLength)
+	if err != nil {
+		return
+	}
+	n, err := readCount(buf)
+	if err != nil {
+		return
+	}
+	for range n {
+		var id string
+		id, err = wire.ReadString(buf, wire.MaxStringLength)
+		if err != nil {
+			return
+		}
+		var hasData byte
+		hasData, err = buf.ReadByte()
+		if err != nil {
+			return
+		}
+		if hasData != 0 {
+			_, err = readNbt(buf)
+			if err != nil {
+				return
+			}
+		}
+		registries[registry] = append(registries[registry], id)
+	}
+	return
+}
+
+// readRegistryCodec adds the entry IDs of all registries to registries,
+// as sent in a single NBT compound before 1.20.5.
+// Registries that are not shaped as expected are skipped.
+//
+// https://minecraft.wiki/w/Java_Edition_protocol/Registry_data
+func readRegistryCodec(buf *bytes.Buffer, registries map[string][]string) (err error) {
+	v, err := readNbt(buf)
+	if err != nil {
+		return
+	}
+	codec, _ := v.(map[string]any)
+	for registry, v := range codec {
+		r, _ := v.(map[string]any)
+		entries, _ := r["value"].([]any)
+		for _, e := range entries {
+			e, _ := e.(map[string]any)
+			if id, ok := e["name"].(string); ok {
+				registries[registry] = append(registries[registry], id)
+			}
+		}
+	}
+	return
+}
+
+// readText reads a text component, which is NBT since 1.20.3 and JSON before.
+func readText(buf *bytes.Buffer, protocol int32) (t Text, err error) {
+	if protocol >= nbtTextProtocol {
+		var v any
+		v, err = readNbt(buf)
+		return nbtText(v), err
+	}
+	s, err := wire.ReadString(buf, wire.MaxChatLength)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal([]byte(s), &t)
+	return
+}
+
+// https://minecraft.wiki/w/Java_Edition_protocol/Packets#Server_Links
+func readServerLinks(buf *bytes.Buffer) (links []ServerLink, err error) {
+	n, err := readCount(buf)
+	if err != nil {
+		return
+	}
+	for range n {
+		var link ServerLink
+		var builtIn byte
+		builtIn, err = buf.ReadByte()
+		if err != nil {
+			return
+		}
+		if builtIn != 0 {
+			var i int32
+			i, err = wire.ReadVarInt(buf)
+			if err != nil {
+				return
+			}
+			if i < 0 || int(i) >= len(serverLinkLabels) {
+				return nil, fmt.Errorf("%w: invalid server link label: %v", stage.ErrProtocol, i)
+			}
+			link.Label = normText(serverLinkLabels[i], Text{})
+		} else {
+			var v any
+			v, err = readNbt(buf)
+			if err != nil {
+				return
+			}
+			link.Label = nbtText(v)
+		}
+		link.URL, err = wire.ReadString(buf, wire.MaxStringLength)
+		if err != nil {
+			return
+		}
+		links = append(links, link)
+	}
+	return
+}
+
+// https://minecraft.wiki/w/Java_Edition_protocol/Packets#Custom_Report_Details
+func readReportDetails(buf *bytes.Buffer) (details []ReportDetail, err error) {
+	n, err := readCount(buf)
+	if err != nil {
+		return
+	}
+	for range n {
+		var d ReportDetail
+		d.Title, err = wire.ReadString(buf, 128)
+		if err != nil {
+			return
+		}
+		d.Description, err = wire.ReadString(buf, 4096)
+		if err != nil {
+			return
+		}
+		details = append(details, d)
+	}
+	return
+}
@@ -0,0 +1,102 @@
+package mc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"bhv.sh/minefetch/internal/wire"
+)
+
+// configPacket appends a configuration packet with the ID id of protocol to buf.
+func configPacket(buf *bytes.Buffer, protocol int32, id int32, data []byte) {
+	id, _ = wire.ConfigurationPacketId(protocol, false, id)
+	b := &bytes.Buffer{}
+	wire.WriteVarInt(b, id)
+	b.Write(data)
+	wire.WritePacket(buf, b.Bytes())
+}
+
+func FuzzConfigure(f *testing.F) {
+	protocol := VersionNameId["latest"]
+	b := &bytes.Buffer{}
+	wire.WriteString(b, "minecraft:brand")
+	wire.WriteString(b, "Paper")
+	brand := bytes.Clone(b.Bytes())
+	b.Reset()
+	wire.WriteVarInt(b, 1)
+	wire.WriteString(b, "minecraft:vanilla")
+	flags := bytes.Clone(b.Bytes())
+	b.Reset()
+	wire.WriteVarInt(b, 1)
+	wire.WriteString(b, "minecraft")
+	wire.WriteString(b, "core")
+	wire.WriteString(b, "1.21.8")
+	packs := bytes.Clone(b.Bytes())
+	b.Reset()
+	wire.WriteString(b, "minecraft:worldgen/biome")
+	wire.WriteVarInt(b, 2)
+	wire.WriteString(b, "minecraft:plains")
+	b.WriteByte(0)
+	wire.WriteString(b, "minecraft:desert")
+	b.WriteByte(1)
+	b.WriteString("\x0a\x01\x00\x0ahas_precip\x00\x00")
+	registry := bytes.Clone(b.Bytes())
+	b.Reset()
+	wire.WriteVarInt(b, 2)
+	b.WriteByte(1)
+	wire.WriteVarInt(b, 0)
+	wire.WriteString(b, "https://example.com/bugs")
+	b.WriteByte(0)
+	b.WriteString("\x08\x00\x04Wiki")
+	wire.WriteString(b, "https://example.com/wiki")
+	links := bytes.Clone(b.Bytes())
+	b.Reset()
+	wire.WriteVarInt(b, 1)
+	wire.WriteString(b, "Server")
+	wire.WriteString(b, "Paper")
+	details := bytes.Clone(b.Bytes())
+	b.Reset()
+	b.Write(make([]byte, 16))
+	wire.WriteString(b, "https://example.com/pack.zip")
+	wire.WriteString(b, "")
+	b.Write([]byte{0, 0})
+	pack := bytes.Clone(b.Bytes())
+
+	buf := &bytes.Buffer{}
+	configPacket(buf, protocol, wire.ConfigurationPacketIdClientboundPluginMessage, brand)
+	configPacket(buf, protocol, wire.ConfigurationPacketIdFeatureFlags, flags)
+	configPacket(buf, protocol, wire.ConfigurationPacketIdClientboundKnownPacks, packs)
+	configPacket(buf, protocol, wire.ConfigurationPacketIdRegistryData, registry)
+	configPacket(buf, protocol, wire.ConfigurationPacketIdServerLinks, links)
+	configPacket(buf, protocol, wire.ConfigurationPacketIdCustomReportDetails, details)
+	configPacket(buf, protocol, wire.ConfigurationPacketIdAddResourcePack, pack)
+	configPacket(buf, protocol, wire.ConfigurationPacketIdClientboundKeepAlive, make([]byte, 8))
+	configPacket(buf, protocol, wire.ConfigurationPacketIdFinishConfiguration, nil)
+	f.Add(buf.Bytes(), protocol)
+
+	// The registry codec and JSON text before 1.20.5
+	buf = &bytes.Buffer{}
+	codec := []byte("\x0a\x0a\x00\x18minecraft:worldgen/biome\x09\x00\x05value\x0a\x00\x00\x00\x01\x08\x00\x04name\x00\x10minecraft:plains\x00\x00\x00")
+	configPacket(buf, 764, wire.ConfigurationPacketIdRegistryData, codec)
+	b.Reset()
+	wire.WriteString(b, `{"text":"Server closed"}`)
+	configPacket(buf, 764, wire.ConfigurationPacketIdDisconnect, b.Bytes())
+	f.Add(buf.Bytes(), int32(764))
+
+	f.Fuzz(func(t *testing.T, b []byte, protocol int32) {
+		conn := struct {
+			io.Reader
+			io.Writer
+		}{bytes.NewReader(b), io.Discard}
+		config := configure(conn, -1, protocol)
+		if config.Err == nil {
+			for _, link := range config.ServerLinks {
+				link.Label.Ansi()
+			}
+			for _, pack := range config.ResourcePacks {
+				pack.Prompt.Ansi()
+			}
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package mc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"bhv.sh/minefetch/internal/stage"
+	"bhv.sh/minefetch/internal/wire"
+)
+
+// DiagnoseStatus runs the same steps as Status one at a time,
+// recording the outcome and duration of each.
+//
+// Unlike Status, a failed ping is reported.
+// Each step that waits on the server is given Timeout to complete.
+// Steps following a failed step are not run.
+func (c *Client) DiagnoseStatus(ctx context.Context, address string) (results []StepResult) {
+	host, port, conn, ok := c.diagnoseConnect(ctx, &results, "tcp", address, 25565)
+	if !ok {
+		return
+	}
+	defer conn.Close()
+	// Count below the trace, which must see the connection itself
+	counter := &countingConn{Conn: conn}
+	conn = c.traced(counter, wire.ProtocolJava)
+
+	ok = stage.Run(&results, stage.Handshake, func() (string, error) {
+		conn.SetDeadline(c.deadline(ctx))
+		hsHost, hsPort := c.handshakeAddr(host, port)
+		return "", writeHandshake(conn, c.protocol(), hsHost, hsPort, intentStatus)
+	})
+	if !ok {
+		return
+	}
+
+	ok = stage.Run(&results, stage.StatusResponse, func() (string, error) {
+		conn.SetDeadline(c.deadline(ctx))
+		err := writeStatusRequest(conn)
+		if err != nil {
+			return "", err
+		}
+		_, err = readStatusResponse(conn)
+		return fmt.Sprint(counter.n, " bytes"), err
+	})
+	if !ok {
+		return
+	}
+
+	stage.Run(&results, stage.Ping, func() (string, error) {
+		conn.SetDeadline(c.deadline(ctx))
+		t := time.Now().Unix()
+		err := writePingRequest(conn, t)
+		if err != nil {
+			return "", err
+		}
+		return "", readPongResponse(conn, t)
+	})
+	return
+}
+
+// DiagnoseQuery runs the same steps as Query one at a time,
+// recording the outcome and duration of each.
+//
+// See DiagnoseStatus for details.
+func (c *Client) DiagnoseQuery(ctx context.Context, address string) (results []StepResult) {
+	_, _, conn, ok := c.diagnoseConnect(ctx, &results, "udp", address, 25565)
+	if !ok {
+		return
+	}
+	defer conn.Close()
+	counter := &countingConn{Conn: conn}
+	conn = c.traced(counter, wire.ProtocolQuery)
+
+	id := int32(time.Now().Unix()) & 0x0f0f0f0f
+	var token int32
+	ok = stage.Run(&results, stage.QueryHandshake, func() (string, error) {
+		conn.SetDeadline(c.deadline(ctx))
+		err := writeQueryHandshake(conn, id)
+		if err != nil {
+			return "", err
+		}
+		token, err = readQueryHandshake(conn, id)
+		return "", err
+	})
+	if !ok {
+		return
+	}
+
+	stage.Run(&results, stage.QueryStatus, func() (string, error) {
+		conn.SetDeadline(c.deadline(ctx))
+		err := writeQueryStatus(conn, id, token)
+		if err != nil {
+			return "", err
+		}
+		counter.n = 0
+		_, err = readQueryStatus(conn, id)
+		return fmt.Sprint(counter.n, " bytes"), err
+	})
+	return
+}
+
+// diagnoseConnect runs the SRV lookup, A/AAAA lookup and connect steps.
+func (c *Client) diagnoseConnect(ctx context.Context, results *[]StepResult, network, address string, defPort uint16) (host string, port uint16, conn net.Conn, ok bool) {
+	host, port, noPort := splitHostPort(address, defPort)
+	ip := net.ParseIP(host)
+
+	if ip == nil {
+		stage.Run(results, stage.SrvLookup, func() (string, error) {
+			target, srvPort, found := c.lookupSrv(ctx, host)
+			if !found {
+				return "none", nil
+			}
+			host = target
+			if noPort {
+				port = srvPort
+			}
+			return JoinHostPort(target, srvPort), nil
+		})
+
+		ok = stage.Run(results, stage.Lookup, func() (string, error) {
+			ips, err := c.resolver().LookupIPAddr(ctx, host)
+			if err != nil {
+				return "", err
+			}
+			if len(ips) == 0 {
+				return "", &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+			}
+			ip = ips[0].IP
+			ss := make([]string, len(ips))
+			for i, ip := range ips {
+				ss[i] = ip.String()
+			}
+			return strings.Join(ss, ", "), nil
+		})
+		if !ok {
+			return
+		}
+	}
+
+	ok = stage.Run(results, stage.Connect, func() (s string, err error) {
+		conn, err = c.dial(ctx, network, JoinHostPort(ip.String(), port))
+		if err != nil {
+			return
+		}
+		return conn.RemoteAddr().String(), nil
+	})
+	return
+}
+
+type countingConn struct {
+	net.Conn
+	n int
+}
+
+func (c *countingConn) Read(p []byte) (n int, err error) {
+	n, err = c.Conn.Read(p)
+	c.n += n
+	return
+}
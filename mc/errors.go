@@ -0,0 +1,51 @@
+package mc
+
+import "bhv.sh/minefetch/internal/stage"
+
+// Error is the type of errors returned by Client requests.
+// It records the step that failed, e.g. "Connect", and why.
+type Error = stage.Error
+
+// ErrorKind classifies why a step failed.
+type ErrorKind = stage.Kind
+
+const (
+	KindUnknown  = stage.Unknown
+	KindNotFound = stage.NotFound // The domain does not exist (NXDOMAIN)
+	KindRefused  = stage.Refused  // Nothing is listening on the port
+	KindReset    = stage.Reset    // The connection was reset or closed early
+	KindTimeout  = stage.Timeout  // No response was received in time
+	KindProtocol = stage.Protocol // The server sent data that does not follow the protocol
+	KindPacketId = stage.PacketId // The server sent an unexpected packet
+	KindJson     = stage.Json     // The server sent malformed JSON
+)
+
+// Steps recorded in Error and StepResult.
+const (
+	StepSrvLookup       = stage.SrvLookup
+	StepLookup          = stage.Lookup
+	StepConnect         = stage.Connect
+	StepHandshake       = stage.Handshake
+	StepStatusResponse  = stage.StatusResponse
+	StepPing            = stage.Ping
+	StepLogin           = stage.Login
+	StepConfiguration   = stage.Configuration
+	StepRconLogin       = stage.RconLogin
+	StepQueryHandshake  = stage.QueryHandshake
+	StepQueryStatus     = stage.QueryStatus
+	StepPong            = stage.Pong
+	StepOpenConnection1 = stage.OpenConnection1
+	StepOpenConnection2 = stage.OpenConnection2
+)
+
+// ClassifyError determines the ErrorKind of any error,
+// which is the Kind of the Error it wraps if there is one.
+func ClassifyError(err error) ErrorKind {
+	return stage.Classify(err)
+}
+
+// ErrProtocol is wrapped by errors caused by data that does not follow the protocol.
+var ErrProtocol = stage.ErrProtocol
+
+// StepResult is the outcome of a single step run by the Diagnose methods.
+type StepResult = stage.Result
@@ -5,6 +5,8 @@ import (
 	"cmp"
 	"encoding/binary"
 	"io"
+
+	"bhv.sh/minefetch/internal/wire"
 )
 
 type intent int32
@@ -18,14 +20,14 @@ const (
 // https://minecraft.wiki/w/Java_Edition_protocol/Packets#Handshake
 func writeHandshake(w io.Writer, proto int32, host string, port uint16, intent intent) error {
 	buf := &bytes.Buffer{}
-	err1 := writeVarInt(buf, handshakePacketId)
-	err2 := writeVarInt(buf, proto)
-	err3 := writeString(buf, host)
+	err1 := wire.WriteVarInt(buf, wire.HandshakePacketId)
+	err2 := wire.WriteVarInt(buf, proto)
+	err3 := wire.WriteString(buf, host)
 	err4 := binary.Write(buf, binary.BigEndian, port)
-	err5 := writeVarInt(buf, int32(intent))
+	err5 := wire.WriteVarInt(buf, int32(intent))
 	if err := cmp.Or(err1, err2, err3, err4, err5); err != nil {
 		return err
 	}
 
-	return writePacket(w, buf.Bytes())
+	return wire.WritePacket(w, buf.Bytes())
 }
@@ -0,0 +1,95 @@
+package mc
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LanInterval is how often clients announce worlds opened to LAN.
+const LanInterval = 1500 * time.Millisecond
+
+var lanGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 2, 60), Port: 4445}
+
+// LanWorld is a world opened to LAN by a client.
+//
+// Motd is usually the player name followed by the world name.
+type LanWorld struct {
+	Motd string
+	Host string
+	Port uint16
+}
+
+// DiscoverLan listens for [LAN world announcements] for duration d.
+//
+// Clients announce worlds opened to LAN every LanInterval,
+// so d should be at least that long.
+//
+// [LAN world announcements]: https://minecraft.wiki/w/Java_Edition_protocol/Server_List_Ping#Ping_via_LAN_(Open_to_LAN_in_Singleplayer)
+func DiscoverLan(d time.Duration) (worlds []LanWorld, err error) {
+	conn, err := net.ListenMulticastUDP("udp4", nil, lanGroup)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	err = conn.SetReadDeadline(time.Now().Add(d))
+	if err != nil {
+		return
+	}
+
+	seen := make(map[string]bool)
+	b := make([]byte, 1024)
+	for {
+		var n int
+		var addr *net.UDPAddr
+		n, addr, err = conn.ReadFromUDP(b)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return worlds, nil
+		}
+		if err != nil {
+			return
+		}
+
+		world, ok := parseLanAnnouncement(string(b[:n]))
+		if !ok {
+			continue
+		}
+		world.Host = addr.IP.String()
+		address := JoinHostPort(world.Host, world.Port)
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+		worlds = append(worlds, world)
+	}
+}
+
+// parseLanAnnouncement parses messages of the form "[MOTD]motd[/MOTD][AD]port[/AD]".
+func parseLanAnnouncement(s string) (world LanWorld, ok bool) {
+	motd, ok := between(s, "[MOTD]", "[/MOTD]")
+	if !ok {
+		return
+	}
+	ad, ok := between(s, "[AD]", "[/AD]")
+	if !ok {
+		return
+	}
+	port, err := strconv.ParseUint(ad, 10, 16)
+	if err != nil {
+		return world, false
+	}
+	return LanWorld{Motd: motd, Port: uint16(port)}, true
+}
+
+func between(s, start, end string) (string, bool) {
+	_, s, ok := strings.Cut(s, start)
+	if !ok {
+		return "", false
+	}
+	s, _, ok = strings.Cut(s, end)
+	return s, ok
+}
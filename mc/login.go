@@ -0,0 +1,230 @@
+package mc
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"bhv.sh/minefetch/internal/stage"
+	"bhv.sh/minefetch/internal/wire"
+)
+
+// LoginResponse contains the outcome of an unauthenticated login attempt.
+//
+// Cracked reports whether the server has online mode disabled.
+// Whitelisted reports whether the server rejected the login because of its whitelist,
+// in which case Cracked is also true, as online mode servers check the session first.
+// Compression is the threshold sent with Set Compression, or -1 if compression was not enabled.
+//
+// Config is what the server sent during the configuration phase,
+// or nil if the login failed or the protocol version is older than 1.20.2.
+// Encryption is the Encryption Request sent by online mode servers, or nil if there was none.
+type LoginResponse struct {
+	Cracked     bool
+	Whitelisted bool
+	Compression int
+	Config      *ConfigResponse
+	Encryption  *EncryptionRequest
+}
+
+// EncryptionRequest is sent by online mode servers to start encryption and authentication.
+//
+// ServerID is empty since 1.7.
+// PublicKey is the DER-encoded RSA public key of the server, KeySize its modulus size in bits
+// and Fingerprint the hex-encoded SHA-256 hash of PublicKey.
+// As servers generate their key pair on startup, addresses sharing a fingerprint lead to the same server process.
+// Authenticate reports whether clients must join through the session server, which is always true before 1.20.5.
+type EncryptionRequest struct {
+	ServerID     string
+	PublicKey    []byte
+	KeySize      int
+	Fingerprint  string
+	Authenticate bool
+}
+
+// Login attempts an unauthenticated login to the server at address to determine whether it has online mode disabled.
+//
+// Whitelist detection is not accurate as servers can customize the disconnect message.
+// Online mode servers answer with an Encryption Request, which is decoded but not answered.
+//
+// If the login succeeds, the configuration phase is read until the server finishes it,
+// and the connection is closed before entering play.
+// Timeout applies to the login and to the configuration phase separately.
+//
+// Note that login attempts are logged in the server console,
+// and operators will see an unexpected disconnect message there.
+func (c *Client) Login(ctx context.Context, address string) (login LoginResponse, err error) {
+	login.Compression = -1
+	host, port := c.lookupHostPort(ctx, address, 25565)
+
+	address = JoinHostPort(host, port)
+	conn, err := c.dial(ctx, "tcp", address)
+	if err != nil {
+		err = stage.Wrap(stage.Connect, err)
+		return
+	}
+	conn = c.traced(conn, wire.ProtocolJava)
+	defer conn.Close()
+	conn.SetDeadline(c.deadline(ctx))
+
+	hsHost, hsPort := c.handshakeAddr(host, port)
+	err = writeHandshake(conn, c.protocol(), hsHost, hsPort, intentLogin)
+	if err != nil {
+		err = stage.Wrap(stage.Handshake, err)
+		return
+	}
+
+	err = writeLoginStart(conn, "minefetch", uuid{})
+	if err != nil {
+		err = stage.Wrap(stage.Login, err)
+		return
+	}
+
+	id, buf, err := wire.ReadPacket(conn)
+	if err != nil {
+		err = stage.Wrap(stage.Login, err)
+		return
+	}
+
+	if id == wire.LoginPacketIdDisconnect {
+		var s string
+		s, err = wire.ReadString(buf, wire.MaxChatLength)
+		if err != nil {
+			err = stage.Wrap(stage.Login, err)
+			return
+		}
+
+		var v map[string]any
+		err = json.Unmarshal([]byte(s), &v)
+		if err != nil {
+			err = errors.New("disconnected: " + s)
+			return
+		}
+		if v, ok := v["translate"]; ok {
+			if v == "multiplayer.disconnect.not_whitelisted" {
+				login.Cracked, login.Whitelisted = true, true
+			} else {
+				err = fmt.Errorf("disconnected: %v", v)
+			}
+			return
+		}
+
+		t := normText(v, Text{})
+		err = fmt.Errorf("disconnected: %v", LegacyTextPlain(t.Raw()))
+		return
+	}
+
+	if id == wire.LoginPacketIdEncryptionRequest {
+		var encryption EncryptionRequest
+		encryption, err = readEncryptionRequest(buf, c.protocol())
+		if err != nil {
+			err = stage.Wrap(stage.Login, err)
+			return
+		}
+		login.Encryption = &encryption
+		return
+	}
+
+	if id == wire.LoginPacketIdSetCompression {
+		var threshold int32
+		threshold, err = wire.ReadVarInt(buf)
+		if err != nil {
+			err = stage.Wrap(stage.Login, err)
+			return
+		}
+		login.Compression = int(max(threshold, -1))
+		// A negative threshold turns compression off
+		read := wire.ReadPacket
+		if threshold >= 0 {
+			read = wire.ReadCompressedPacket
+		}
+		id, _, err = read(conn)
+		if err != nil {
+			err = stage.Wrap(stage.Login, err)
+			return
+		}
+	}
+
+	login.Cracked = id == wire.LoginPacketIdLoginSuccess
+	if !login.Cracked || c.protocol() < configProtocol {
+		return
+	}
+	conn.SetDeadline(c.deadline(ctx))
+	config := configure(conn, login.Compression, c.protocol())
+	login.Config = &config
+	return
+}
+
+// https://minecraft.wiki/w/Java_Edition_protocol/Packets#Encryption_Request
+func readEncryptionRequest(buf *bytes.Buffer, protocol int32) (encryption EncryptionRequest, err error) {
+	encryption.ServerID, err = wire.ReadString(buf, 20)
+	if err != nil {
+		return
+	}
+	encryption.PublicKey, err = readByteArray(buf)
+	if err != nil {
+		return
+	}
+	// The verify token is only needed to answer
+	_, err = readByteArray(buf)
+	if err != nil {
+		return
+	}
+	encryption.Authenticate = true
+	// Since 1.20.5
+	if protocol >= knownPacksProtocol {
+		var authenticate byte
+		authenticate, err = buf.ReadByte()
+		if err != nil {
+			return
+		}
+		encryption.Authenticate = authenticate != 0
+	}
+
+	key, err := x509.ParsePKIXPublicKey(encryption.PublicKey)
+	if err != nil {
+		err = fmt.Errorf("%w: invalid public key: %w", stage.ErrProtocol, err)
+		return
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		err = fmt.Errorf("%w: public key is not RSA", stage.ErrProtocol)
+		return
+	}
+	encryption.KeySize = rsaKey.N.BitLen()
+	sum := sha256.Sum256(encryption.PublicKey)
+	encryption.Fingerprint = hex.EncodeToString(sum[:])
+	return
+}
+
+// readByteArray reads a byte array prefixed with its VarInt length.
+func readByteArray(buf *bytes.Buffer) (b []byte, err error) {
+	n, err := readCount(buf)
+	if err != nil {
+		return
+	}
+	return bytes.Clone(buf.Next(n)), nil
+}
+
+type uuid [16]byte
+
+func writeLoginStart(w io.Writer, user string, uuid uuid) error {
+	buf := &bytes.Buffer{}
+	err1 := wire.WriteVarInt(buf, wire.LoginPacketIdLoginStart)
+	err2 := wire.WriteString(buf, user)
+	err3 := binary.Write(buf, binary.BigEndian, uuid)
+	if err := cmp.Or(err1, err2, err3); err != nil {
+		return err
+	}
+
+	return wire.WritePacket(w, buf.Bytes())
+}
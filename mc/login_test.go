@@ -0,0 +1,40 @@
+package mc
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+
+	"bhv.sh/minefetch/internal/wire"
+)
+
+func FuzzReadEncryptionRequest(f *testing.F) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		f.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, protocol := range []int32{765, 766} {
+		buf := &bytes.Buffer{}
+		wire.WriteString(buf, "")
+		wire.WriteVarInt(buf, int32(len(der)))
+		buf.Write(der)
+		wire.WriteVarInt(buf, 4)
+		buf.Write([]byte{1, 2, 3, 4})
+		if protocol >= knownPacksProtocol {
+			buf.WriteByte(1)
+		}
+		f.Add(buf.Bytes(), protocol)
+	}
+	f.Fuzz(func(t *testing.T, b []byte, protocol int32) {
+		encryption, err := readEncryptionRequest(bytes.NewBuffer(b), protocol)
+		if err == nil && (encryption.KeySize == 0 || len(encryption.Fingerprint) != 64) {
+			t.Errorf("read a %v bit key with fingerprint %q", encryption.KeySize, encryption.Fingerprint)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package mctest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+
+	"bhv.sh/minefetch/internal/wire"
+)
+
+// BedrockPing is an unconnected ping received by a BedrockServer.
+type BedrockPing struct {
+	Time       int64
+	ClientGUID int64
+}
+
+// BedrockServer is a fake Bedrock Edition server answering [unconnected pings] with pongs.
+// The RakNet connection handshake is not supported.
+//
+// [unconnected pings]: https://minecraft.wiki/w/RakNet#Unconnected_Ping
+type BedrockServer struct {
+	// Pong is the server info string sent in pongs, such as
+	// "MCPE;Dedicated Server;766;1.21.50;0;10;1234;Bedrock level;Survival;1;19132;19133;".
+	Pong string
+
+	// GUID is the server GUID sent in pongs.
+	GUID int64
+
+	// Drop is the number of pings to ignore before answering, to test retries.
+	Drop int
+
+	udpServer
+	mu    sync.Mutex
+	pings []BedrockPing
+}
+
+// Start starts listening on a random loopback port.
+func (s *BedrockServer) Start() error {
+	return s.start(s.Drop, s.serve)
+}
+
+// Addr returns the address the server listens on, or "" if it has not started.
+func (s *BedrockServer) Addr() string {
+	return s.addr()
+}
+
+// Close stops the server.
+func (s *BedrockServer) Close() error {
+	return s.close()
+}
+
+// Pings returns the answered pings in the order they were received.
+func (s *BedrockServer) Pings() []BedrockPing {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]BedrockPing(nil), s.pings...)
+}
+
+// https://minecraft.wiki/w/RakNet#Unconnected_Ping
+func (s *BedrockServer) serve(b []byte) []byte {
+	r := bytes.NewReader(b)
+	id, err := r.ReadByte()
+	if err != nil || id != wire.RakNetPacketIdUnconnectedPing {
+		return nil
+	}
+	var ping BedrockPing
+	var magic [16]byte
+	binary.Read(r, binary.BigEndian, &ping.Time)
+	binary.Read(r, binary.BigEndian, &magic)
+	err = binary.Read(r, binary.BigEndian, &ping.ClientGUID)
+	if err != nil || magic != wire.RakNetMagic {
+		return nil
+	}
+	s.mu.Lock()
+	s.pings = append(s.pings, ping)
+	s.mu.Unlock()
+
+	// https://minecraft.wiki/w/RakNet#Unconnected_Pong
+	buf := &bytes.Buffer{}
+	buf.WriteByte(wire.RakNetPacketIdUnconnectedPong)
+	binary.Write(buf, binary.BigEndian, ping.Time)
+	binary.Write(buf, binary.BigEndian, s.GUID)
+	binary.Write(buf, binary.BigEndian, wire.RakNetMagic)
+	binary.Write(buf, binary.BigEndian, uint16(len(s.Pong)))
+	buf.WriteString(s.Pong)
+	return buf.Bytes()
+}
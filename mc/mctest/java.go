@@ -0,0 +1,305 @@
+package mctest
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"slices"
+	"sync"
+
+	"bhv.sh/minefetch/internal/stage"
+	"bhv.sh/minefetch/internal/wire"
+)
+
+// Handshake is the first packet sent by Java Edition clients.
+//
+// Intent is 1 for status requests, 2 for logins and 3 for transfers.
+type Handshake struct {
+	Protocol int32
+	Host     string
+	Port     uint16
+	Intent   int32
+}
+
+// JavaRequest is what a client sent on a single connection to a JavaServer.
+// Packets are those following the handshake, decompressed if compression was enabled.
+type JavaRequest struct {
+	Handshake Handshake
+	Packets   []Packet
+}
+
+// JavaServer is a fake Java Edition server answering status requests, pings and logins.
+type JavaServer struct {
+	// Status is the JSON status response.
+	Status string
+
+	// StatusFunc, if set, returns the status response instead of Status,
+	// given the handshake and the number of status requests received before, starting at 0.
+	// If it returns an empty string, the connection is closed without a response.
+	StatusFunc func(hs Handshake, n int) string
+
+	// Disconnect is the JSON text component sent to disconnect logins.
+	// If empty, logins succeed.
+	Disconnect string
+
+	// PublicKey, if set, is the DER-encoded public key sent in an Encryption Request
+	// to answer logins as an online mode server, after which the connection is closed.
+	PublicKey []byte
+
+	// If Compression is set, Set Compression is sent before Login Success,
+	// which is compressed if it is at least CompressionThreshold bytes long.
+	// A negative CompressionThreshold leaves compression off, and packets are sent uncompressed.
+	Compression          bool
+	CompressionThreshold int
+
+	// Configuration are the packets sent after Login Acknowledged, followed by Finish Configuration.
+	// Their IDs are those of the configuration state of 1.20.5 and later (see [the protocol]),
+	// which are translated to those of 1.20.2 to 1.20.4 if the handshake has their protocol version,
+	// but their data is sent as is.
+	// Packets that do not exist in the protocol version of the handshake are skipped,
+	// and none are sent if the login is disconnected.
+	//
+	// [the protocol]: https://minecraft.wiki/w/Java_Edition_protocol/Packets#Configuration
+	Configuration []Packet
+
+	tcpServer
+	mu       sync.Mutex
+	requests []*JavaRequest
+	statuses int
+}
+
+// Start starts listening on a random loopback port.
+func (s *JavaServer) Start() error {
+	return s.start(s.serve)
+}
+
+// Addr returns the address the server listens on, or "" if it has not started.
+func (s *JavaServer) Addr() string {
+	return s.addr()
+}
+
+// Close stops the server and closes all connections.
+func (s *JavaServer) Close() error {
+	return s.close()
+}
+
+// Requests returns what clients sent, one request per connection, in the order they connected.
+func (s *JavaServer) Requests() []JavaRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	requests := make([]JavaRequest, len(s.requests))
+	for i, r := range s.requests {
+		requests[i] = JavaRequest{r.Handshake, append([]Packet(nil), r.Packets...)}
+	}
+	return requests
+}
+
+func (s *JavaServer) serve(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	hs, err := readHandshake(r)
+	if err != nil {
+		return
+	}
+	req := &JavaRequest{Handshake: hs}
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	compressed, configuring := false, false
+	for {
+		read := wire.ReadPacket
+		if compressed {
+			read = wire.ReadCompressedPacket
+		}
+		id, buf, err := read(r)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		req.Packets = append(req.Packets, Packet{id, buf.Bytes()})
+		s.mu.Unlock()
+
+		switch {
+		case hs.Intent == 1 && id == wire.StatusPacketIdStatusRequest:
+			err = s.writeStatus(conn, hs)
+		case hs.Intent == 1 && id == wire.StatusPacketIdPingRequest:
+			// The pong echoes the ping payload
+			err = writePacket(conn, wire.StatusPacketIdPongResponse, buf.Bytes())
+		case hs.Intent != 1 && !configuring && id == wire.LoginPacketIdLoginStart:
+			compressed, err = s.writeLogin(conn, hs, buf)
+		case hs.Intent != 1 && !configuring && id == wire.LoginPacketIdLoginAcknowledged:
+			configuring = true
+			err = s.writeConfiguration(conn, hs.Protocol, compressed)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *JavaServer) writeStatus(w io.Writer, hs Handshake) error {
+	s.mu.Lock()
+	n := s.statuses
+	s.statuses++
+	s.mu.Unlock()
+
+	status := s.Status
+	if s.StatusFunc != nil {
+		status = s.StatusFunc(hs, n)
+	}
+	if status == "" {
+		return io.EOF
+	}
+	buf := &bytes.Buffer{}
+	err := wire.WriteString(buf, status)
+	if err != nil {
+		return err
+	}
+	return writePacket(w, wire.StatusPacketIdStatusResponse, buf.Bytes())
+}
+
+// writeLogin answers Login Start, and reports whether compression was enabled.
+func (s *JavaServer) writeLogin(w io.Writer, hs Handshake, loginStart *bytes.Buffer) (compressed bool, err error) {
+	if s.Disconnect != "" {
+		buf := &bytes.Buffer{}
+		err = wire.WriteString(buf, s.Disconnect)
+		if err != nil {
+			return
+		}
+		return false, writePacket(w, wire.LoginPacketIdDisconnect, buf.Bytes())
+	}
+
+	if s.PublicKey != nil {
+		return false, writeEncryptionRequest(w, hs.Protocol, s.PublicKey)
+	}
+
+	// Login Success echoes the name and UUID from Login Start
+	name, err := wire.ReadString(loginStart, 16)
+	if err != nil {
+		return
+	}
+	var uuid [16]byte
+	err = binary.Read(loginStart, binary.BigEndian, &uuid)
+	if err != nil {
+		return
+	}
+	buf := &bytes.Buffer{}
+	err1 := wire.WriteVarInt(buf, wire.LoginPacketIdLoginSuccess)
+	_, err2 := buf.Write(uuid[:])
+	err3 := wire.WriteString(buf, name)
+	// No properties
+	err4 := wire.WriteVarInt(buf, 0)
+	if err = cmp.Or(err1, err2, err3, err4); err != nil {
+		return
+	}
+
+	if !s.Compression {
+		return false, wire.WritePacket(w, buf.Bytes())
+	}
+	threshold := &bytes.Buffer{}
+	err = wire.WriteVarInt(threshold, int32(s.CompressionThreshold))
+	if err != nil {
+		return
+	}
+	err = writePacket(w, wire.LoginPacketIdSetCompression, threshold.Bytes())
+	if err != nil {
+		return
+	}
+	if s.CompressionThreshold < 0 {
+		return false, wire.WritePacket(w, buf.Bytes())
+	}
+	return true, wire.WriteCompressedPacket(w, buf.Bytes(), s.CompressionThreshold)
+}
+
+// writeConfiguration sends the configuration packets and Finish Configuration with the IDs of protocol.
+func (s *JavaServer) writeConfiguration(w io.Writer, protocol int32, compressed bool) error {
+	packets := slices.Concat(s.Configuration, []Packet{{ID: wire.ConfigurationPacketIdFinishConfiguration}})
+	for _, p := range packets {
+		id, ok := wire.ConfigurationPacketId(protocol, false, p.ID)
+		if !ok {
+			continue
+		}
+		buf := &bytes.Buffer{}
+		err1 := wire.WriteVarInt(buf, id)
+		_, err2 := buf.Write(p.Data)
+		if err := cmp.Or(err1, err2); err != nil {
+			return err
+		}
+		var err error
+		if compressed {
+			err = wire.WriteCompressedPacket(w, buf.Bytes(), s.CompressionThreshold)
+		} else {
+			err = wire.WritePacket(w, buf.Bytes())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEncryptionRequest writes an Encryption Request with an empty server ID and a random verify token,
+// and returns io.EOF to close the connection.
+//
+// https://minecraft.wiki/w/Java_Edition_protocol/Packets#Encryption_Request
+func writeEncryptionRequest(w io.Writer, protocol int32, key []byte) error {
+	token := make([]byte, 4)
+	rand.Read(token)
+	buf := &bytes.Buffer{}
+	err1 := wire.WriteString(buf, "")
+	err2 := wire.WriteVarInt(buf, int32(len(key)))
+	_, err3 := buf.Write(key)
+	err4 := wire.WriteVarInt(buf, int32(len(token)))
+	_, err5 := buf.Write(token)
+	var err6 error
+	// Should authenticate, since 1.20.5
+	if protocol >= 766 {
+		err6 = buf.WriteByte(1)
+	}
+	if err := cmp.Or(err1, err2, err3, err4, err5, err6); err != nil {
+		return err
+	}
+	err := writePacket(w, wire.LoginPacketIdEncryptionRequest, buf.Bytes())
+	return cmp.Or(err, io.EOF)
+}
+
+// https://minecraft.wiki/w/Java_Edition_protocol/Packets#Handshake
+func readHandshake(r io.Reader) (hs Handshake, err error) {
+	id, buf, err := wire.ReadPacket(r)
+	if err != nil {
+		return
+	}
+	if id != wire.HandshakePacketId {
+		err = &stage.PacketIdError{Id: id}
+		return
+	}
+	hs.Protocol, err = wire.ReadVarInt(buf)
+	if err != nil {
+		return
+	}
+	hs.Host, err = wire.ReadString(buf, 255)
+	if err != nil {
+		return
+	}
+	err = binary.Read(buf, binary.BigEndian, &hs.Port)
+	if err != nil {
+		return
+	}
+	hs.Intent, err = wire.ReadVarInt(buf)
+	return
+}
+
+// writePacket writes a packet with the given ID and data without compression.
+func writePacket(w io.Writer, id int32, data []byte) error {
+	buf := &bytes.Buffer{}
+	err1 := wire.WriteVarInt(buf, id)
+	_, err2 := buf.Write(data)
+	if err := cmp.Or(err1, err2); err != nil {
+		return err
+	}
+	return wire.WritePacket(w, buf.Bytes())
+}
@@ -0,0 +1,148 @@
+// Package mctest provides fake servers for testing code that uses the mc and mcpe packages
+// without a real server or network.
+//
+// Each server listens on a random loopback port once started,
+// answers requests as configured by its fields, and records what clients sent.
+// Fields must not be modified after Start.
+//
+//	s := &mctest.JavaServer{Status: `{"version":{"name":"1.21.4","protocol":769}}`}
+//	err := s.Start()
+//	...
+//	defer s.Close()
+//	status, err := client.Status(ctx, s.Addr())
+package mctest
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+// Packet is a packet received by a server, with its ID and the rest of its data.
+type Packet struct {
+	ID   int32
+	Data []byte
+}
+
+// tcpServer accepts connections on loopback and serves each in a goroutine.
+// Connections accepted after close are closed right away.
+type tcpServer struct {
+	ln     net.Listener
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+func (s *tcpServer) start(serve func(conn net.Conn)) (err error) {
+	if s.ln != nil {
+		return errors.New("server already started")
+	}
+	s.ln, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return
+	}
+	s.conns = make(map[net.Conn]struct{})
+	s.wg.Go(func() {
+		for {
+			conn, err := s.ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			if s.closed {
+				s.mu.Unlock()
+				conn.Close()
+				return
+			}
+			s.conns[conn] = struct{}{}
+			s.mu.Unlock()
+			s.wg.Go(func() {
+				defer func() {
+					s.mu.Lock()
+					delete(s.conns, conn)
+					s.mu.Unlock()
+					conn.Close()
+				}()
+				serve(conn)
+			})
+		}
+	})
+	return
+}
+
+func (s *tcpServer) addr() string {
+	if s.ln == nil {
+		return ""
+	}
+	return s.ln.Addr().String()
+}
+
+// close stops accepting connections, closes open ones and waits for them to be served.
+func (s *tcpServer) close() error {
+	if s.ln == nil {
+		return nil
+	}
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	err := s.ln.Close()
+	s.wg.Wait()
+	return err
+}
+
+// udpServer reads datagrams on loopback and answers them in order.
+//
+// The first drop datagrams are ignored, to let clients time out and retry.
+type udpServer struct {
+	pc   net.PacketConn
+	wg   sync.WaitGroup
+	drop int
+}
+
+func (s *udpServer) start(drop int, serve func(b []byte) []byte) (err error) {
+	if s.pc != nil {
+		return errors.New("server already started")
+	}
+	s.pc, err = net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return
+	}
+	s.drop = drop
+	s.wg.Go(func() {
+		buf := make([]byte, 1<<16)
+		for {
+			n, addr, err := s.pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if s.drop > 0 {
+				s.drop--
+				continue
+			}
+			if b := serve(buf[:n]); b != nil {
+				s.pc.WriteTo(b, addr)
+			}
+		}
+	})
+	return
+}
+
+func (s *udpServer) addr() string {
+	if s.pc == nil {
+		return ""
+	}
+	return s.pc.LocalAddr().String()
+}
+
+func (s *udpServer) close() error {
+	if s.pc == nil {
+		return nil
+	}
+	err := s.pc.Close()
+	s.wg.Wait()
+	return err
+}
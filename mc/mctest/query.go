@@ -0,0 +1,126 @@
+package mctest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"sync"
+
+	"bhv.sh/minefetch/internal/wire"
+)
+
+// QueryRequest is a single request received by a QueryServer.
+//
+// Type is 9 for handshakes and 0 for stat requests, which include the Token from the handshake.
+type QueryRequest struct {
+	Type      byte
+	SessionID int32
+	Token     int32
+}
+
+// QueryServer is a fake [query protocol] responder, answering handshakes and full stat requests.
+//
+// [query protocol]: https://minecraft.wiki/w/Query
+type QueryServer struct {
+	// Values are the key-value pairs of the full stat response, in order,
+	// such as {"hostname", "A Minecraft Server"} and {"numplayers", "1"}.
+	// Strings are sent in ISO 8859-1, with other characters replaced by '?'.
+	Values [][2]string
+
+	// Players are the names of the online players.
+	Players []string
+
+	// Token is the challenge token sent in handshake responses.
+	// Stat requests with another token are ignored.
+	Token int32
+
+	// Drop is the number of requests to ignore before answering, to test retries.
+	Drop int
+
+	udpServer
+	mu       sync.Mutex
+	requests []QueryRequest
+}
+
+// Start starts listening on a random loopback port.
+func (s *QueryServer) Start() error {
+	return s.start(s.Drop, s.serve)
+}
+
+// Addr returns the address the server listens on, or "" if it has not started.
+func (s *QueryServer) Addr() string {
+	return s.addr()
+}
+
+// Close stops the server.
+func (s *QueryServer) Close() error {
+	return s.close()
+}
+
+// Requests returns the answered requests in the order they were received.
+func (s *QueryServer) Requests() []QueryRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]QueryRequest(nil), s.requests...)
+}
+
+// https://minecraft.wiki/w/Query#Client_to_Server_Packet_Format
+func (s *QueryServer) serve(b []byte) []byte {
+	r := bytes.NewReader(b)
+	var magic uint16
+	var req QueryRequest
+	binary.Read(r, binary.BigEndian, &magic)
+	binary.Read(r, binary.BigEndian, &req.Type)
+	err := binary.Read(r, binary.BigEndian, &req.SessionID)
+	if err != nil || magic != wire.QueryMagic {
+		return nil
+	}
+	if wire.QueryPacketType(req.Type) == wire.QueryPacketTypeStat {
+		err = binary.Read(r, binary.BigEndian, &req.Token)
+		if err != nil {
+			return nil
+		}
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	buf := &bytes.Buffer{}
+	buf.WriteByte(req.Type)
+	binary.Write(buf, binary.BigEndian, req.SessionID)
+	switch wire.QueryPacketType(req.Type) {
+	case wire.QueryPacketTypeHandshake:
+		buf.WriteString(strconv.Itoa(int(s.Token)))
+		buf.WriteByte(0)
+	case wire.QueryPacketTypeStat:
+		if req.Token != s.Token {
+			return nil
+		}
+		// https://minecraft.wiki/w/Query#Full_stat
+		buf.WriteString("splitnum\x00\x80\x00")
+		for _, kv := range s.Values {
+			writeLatin1(buf, kv[0])
+			writeLatin1(buf, kv[1])
+		}
+		buf.WriteByte(0)
+		buf.WriteString("\x01player_\x00\x00")
+		for _, p := range s.Players {
+			writeLatin1(buf, p)
+		}
+		buf.WriteByte(0)
+	default:
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// writeLatin1 writes s in ISO 8859-1 followed by a null byte.
+func writeLatin1(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		if r > 0xff {
+			r = '?'
+		}
+		buf.WriteByte(byte(r))
+	}
+	buf.WriteByte(0)
+}
@@ -0,0 +1,97 @@
+package mctest
+
+import (
+	"net"
+	"sync"
+
+	"bhv.sh/minefetch/internal/wire"
+)
+
+// RCONPacket is a packet received by an RCONServer.
+//
+// Type is 3 for logins, in which case Payload is the password, and 2 for commands.
+type RCONPacket struct {
+	ID      int32
+	Type    int32
+	Payload string
+}
+
+// RCONServer is a fake [RCON] server.
+//
+// Logins with the wrong password get a response with the ID -1,
+// after which commands are ignored.
+//
+// [RCON]: https://minecraft.wiki/w/RCON
+type RCONServer struct {
+	// Password is the password required to log in.
+	Password string
+
+	// Handler returns the output of a command.
+	// If nil, commands have no output.
+	// Output longer than 4096 bytes is split into several packets, as done by Minecraft servers.
+	Handler func(command string) string
+
+	tcpServer
+	mu      sync.Mutex
+	packets []RCONPacket
+}
+
+// Start starts listening on a random loopback port.
+func (s *RCONServer) Start() error {
+	return s.start(s.serve)
+}
+
+// Addr returns the address the server listens on, or "" if it has not started.
+func (s *RCONServer) Addr() string {
+	return s.addr()
+}
+
+// Close stops the server and closes all connections.
+func (s *RCONServer) Close() error {
+	return s.close()
+}
+
+// Packets returns the packets received on all connections, in the order they were received.
+func (s *RCONServer) Packets() []RCONPacket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]RCONPacket(nil), s.packets...)
+}
+
+func (s *RCONServer) serve(conn net.Conn) {
+	authenticated := false
+	for {
+		id, t, payload, err := wire.ReadRconPacket(conn)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.packets = append(s.packets, RCONPacket{id, t, payload})
+		s.mu.Unlock()
+
+		switch {
+		case t == wire.RconPacketTypeLoginRequest:
+			authenticated = payload == s.Password
+			if !authenticated {
+				id = wire.RconPacketTypeFailed
+			}
+			err = wire.WriteRconPacket(conn, id, wire.RconPacketTypeLoginResponse, "")
+		case t == wire.RconPacketTypeCommand && authenticated:
+			var out string
+			if s.Handler != nil {
+				out = s.Handler(payload)
+			}
+			for {
+				n := min(len(out), wire.MaxRconPayloadLength)
+				err = wire.WriteRconPacket(conn, id, wire.RconPacketTypeMulti, out[:n])
+				out = out[n:]
+				if err != nil || out == "" {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package mc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// NBT tag types.
+//
+// https://minecraft.wiki/w/NBT_format#Binary_format
+const (
+	nbtEnd byte = iota
+	nbtByte
+	nbtShort
+	nbtInt
+	nbtLong
+	nbtFloat
+	nbtDouble
+	nbtByteArray
+	nbtString
+	nbtList
+	nbtCompound
+	nbtIntArray
+	nbtLongArray
+)
+
+// maxNbtDepth is the nesting limit enforced by the client.
+const maxNbtDepth = 512
+
+var errNbtDepth = errors.New("NBT nested too deep")
+
+// readNbt reads an unnamed NBT tag, as sent over the network since 1.20.2.
+//
+// Tags are decoded to int8, int16, int32, int64, float32, float64, []byte, string,
+// []any, map[string]any, []int32 and []int64 respectively.
+// The End tag, sent for absent optional values, is decoded to nil.
+//
+// Strings are modified UTF-8, which is read as UTF-8.
+func readNbt(buf *bytes.Buffer) (v any, err error) {
+	t, err := buf.ReadByte()
+	if err != nil {
+		return
+	}
+	if t == nbtEnd {
+		return
+	}
+	return readNbtPayload(buf, t, 0)
+}
+
+func readNbtPayload(buf *bytes.Buffer, t byte, depth int) (v any, err error) {
+	if depth > maxNbtDepth {
+		return nil, errNbtDepth
+	}
+	switch t {
+	case nbtByte:
+		var b byte
+		b, err = buf.ReadByte()
+		v = int8(b)
+	case nbtShort:
+		var i int16
+		err = binary.Read(buf, binary.BigEndian, &i)
+		v = i
+	case nbtInt:
+		var i int32
+		err = binary.Read(buf, binary.BigEndian, &i)
+		v = i
+	case nbtLong:
+		var i int64
+		err = binary.Read(buf, binary.BigEndian, &i)
+		v = i
+	case nbtFloat:
+		var f float32
+		err = binary.Read(buf, binary.BigEndian, &f)
+		v = f
+	case nbtDouble:
+		var f float64
+		err = binary.Read(buf, binary.BigEndian, &f)
+		v = f
+	case nbtByteArray:
+		var n int
+		n, err = readNbtLength(buf, 1)
+		if err != nil {
+			return
+		}
+		v = bytes.Clone(buf.Next(n))
+	case nbtString:
+		v, err = readNbtString(buf)
+	case nbtList:
+		var et byte
+		et, err = buf.ReadByte()
+		if err != nil {
+			return
+		}
+		var n int
+		n, err = readNbtLength(buf, 1)
+		if err != nil {
+			return
+		}
+		l := make([]any, 0, n)
+		for range n {
+			var e any
+			e, err = readNbtPayload(buf, et, depth+1)
+			if err != nil {
+				return
+			}
+			l = append(l, e)
+		}
+		v = l
+	case nbtCompound:
+		m := map[string]any{}
+		for {
+			var et byte
+			et, err = buf.ReadByte()
+			if err != nil || et == nbtEnd {
+				break
+			}
+			var k string
+			k, err = readNbtString(buf)
+			if err != nil {
+				return
+			}
+			m[k], err = readNbtPayload(buf, et, depth+1)
+			if err != nil {
+				return
+			}
+		}
+		v = m
+	case nbtIntArray:
+		var n int
+		n, err = readNbtLength(buf, 4)
+		if err != nil {
+			return
+		}
+		a := make([]int32, n)
+		err = binary.Read(buf, binary.BigEndian, a)
+		v = a
+	case nbtLongArray:
+		var n int
+		n, err = readNbtLength(buf, 8)
+		if err != nil {
+			return
+		}
+		a := make([]int64, n)
+		err = binary.Read(buf, binary.BigEndian, a)
+		v = a
+	default:
+		err = fmt.Errorf("invalid NBT tag type: %v", t)
+	}
+	return
+}
+
+// readNbtLength reads the length of an array or list,
+// which must fit in buf with each element taking at least size bytes.
+func readNbtLength(buf *bytes.Buffer, size int) (n int, err error) {
+	var i int32
+	err = binary.Read(buf, binary.BigEndian, &i)
+	if err != nil {
+		return
+	}
+	if i < 0 || int(i) > buf.Len()/size {
+		return 0, fmt.Errorf("invalid NBT length: %v", i)
+	}
+	return int(i), nil
+}
+
+func readNbtString(buf *bytes.Buffer) (s string, err error) {
+	var n uint16
+	err = binary.Read(buf, binary.BigEndian, &n)
+	if err != nil {
+		return
+	}
+	if int(n) > buf.Len() {
+		return "", io.ErrUnexpectedEOF
+	}
+	return string(buf.Next(int(n))), nil
+}
+
+// nbtText converts an NBT text component to Text.
+//
+// Booleans such as bold are stored as bytes,
+// and elements of lists of mixed types are wrapped in compounds with an empty key.
+//
+// https://minecraft.wiki/w/Text_component_format#Java_Edition
+func nbtText(v any) Text {
+	return normText(nbtJson(v), Text{})
+}
+
+// nbtJson converts v as returned by readNbt to the values used by encoding/json.
+func nbtJson(v any) any {
+	switch v := v.(type) {
+	case int8:
+		// Text components only use bytes for booleans
+		return v != 0
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []any:
+		l := make([]any, len(v))
+		for i, e := range v {
+			l[i] = nbtJson(e)
+		}
+		return l
+	case map[string]any:
+		if e, ok := v[""]; ok && len(v) == 1 {
+			return nbtJson(e)
+		}
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = nbtJson(e)
+		}
+		return m
+	}
+	return v
+}
@@ -0,0 +1,17 @@
+package mc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzReadNbt(f *testing.F) {
+	// {"text": "hi", "bold": 1b}
+	f.Add([]byte("\x0a\x08\x00\x04text\x00\x02hi\x01\x00\x04bold\x01\x00"))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		v, err := readNbt(bytes.NewBuffer(b))
+		if err == nil {
+			nbtText(v).Ansi()
+		}
+	})
+}
@@ -1,8 +1,10 @@
 package mc
 
 import (
+	"context"
 	"net"
 	"strconv"
+	"strings"
 )
 
 // SplitHostPort is like net.SplitHostPort, but with a uint16 port.
@@ -29,28 +31,39 @@ func JoinHostPort(host string, port uint16) string {
 //   - If address is an IP with no port, return the IP and defPort
 //   - If address is a host with port, return SRV host if it exists, or the address host, both with address port
 //   - If address is a host with no port, return the SRV host and port if they exist, or the host and defPort
-func lookupHostPort(address string, defPort uint16) (host string, port uint16) {
-	var err error
-	host, port, err = SplitHostPort(address)
-	noPort := port == 0 || err != nil
-	if noPort {
-		host = address
-		port = defPort
-		err = nil
-	}
+func (c *Client) lookupHostPort(ctx context.Context, address string, defPort uint16) (host string, port uint16) {
+	host, port, noPort := splitHostPort(address, defPort)
 	if net.ParseIP(host) != nil {
 		return
 	}
-	_, addrs, err := net.LookupSRV("minecraft", "tcp", host)
-	if err != nil || len(addrs) == 0 {
+	target, srvPort, ok := c.lookupSrv(ctx, host)
+	if !ok {
 		return
 	}
-	host = addrs[0].Target
-	if host[len(host)-1] == '.' {
-		host = host[:len(host)-1]
+	host = target
+	if noPort {
+		port = srvPort
 	}
+	return
+}
+
+// splitHostPort is like SplitHostPort, but falls back to address and defPort if address has no port.
+func splitHostPort(address string, defPort uint16) (host string, port uint16, noPort bool) {
+	host, port, err := SplitHostPort(address)
+	noPort = port == 0 || err != nil
 	if noPort {
-		port = addrs[0].Port
+		host = address
+		port = defPort
 	}
 	return
 }
+
+// lookupSrv returns the target of the Minecraft SRV record for host, if one exists.
+func (c *Client) lookupSrv(ctx context.Context, host string) (target string, port uint16, ok bool) {
+	_, addrs, err := c.resolver().LookupSRV(ctx, "minecraft", "tcp", host)
+	if err != nil || len(addrs) == 0 {
+		return
+	}
+	target = strings.TrimSuffix(addrs[0].Target, ".")
+	return target, addrs[0].Port, true
+}
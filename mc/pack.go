@@ -0,0 +1,213 @@
+package mc
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"bhv.sh/minefetch/internal/wire"
+)
+
+// MaxResourcePackSize is the largest resource pack the client downloads.
+const MaxResourcePackSize = 250 << 20
+
+// Results sent in Resource Pack Response.
+//
+// https://minecraft.wiki/w/Java_Edition_protocol/Packets#Resource_Pack_Response_(configuration)
+const (
+	resourcePackLoaded     int32 = 0
+	resourcePackAccepted   int32 = 3
+	resourcePackDownloaded int32 = 4
+)
+
+// ResourcePack is a resource pack sent by a server during the configuration phase.
+// UUID is zero before 1.20.3.
+//
+// Hash is the hex-encoded SHA-1 hash of the pack, which may be empty.
+// Required packs must be accepted to join, and Prompt is the text shown when asking to,
+// which is empty if there is none.
+type ResourcePack struct {
+	UUID     [16]byte
+	URL      string
+	Hash     string
+	Required bool
+	Prompt   Text
+}
+
+// readAddResourcePack reads a resource pack, which has no UUID before 1.20.3.
+//
+// https://minecraft.wiki/w/Java_Edition_protocol/Packets#Add_Resource_Pack_(configuration)
+func readAddResourcePack(buf *bytes.Buffer, protocol int32) (pack ResourcePack, err error) {
+	if protocol >= nbtTextProtocol {
+		_, err = io.ReadFull(buf, pack.UUID[:])
+		if err != nil {
+			return
+		}
+	}
+	pack.URL, err = wire.ReadString(buf, wire.MaxStringLength)
+	if err != nil {
+		return
+	}
+	pack.Hash, err = wire.ReadString(buf, 40)
+	if err != nil {
+		return
+	}
+	required, err := buf.ReadByte()
+	if err != nil {
+		return
+	}
+	pack.Required = required != 0
+	hasPrompt, err := buf.ReadByte()
+	if err != nil || hasPrompt == 0 {
+		return
+	}
+	pack.Prompt, err = readText(buf, protocol)
+	return
+}
+
+// ResourcePackInfo describes a downloaded resource pack.
+//
+// Hash is the hex-encoded SHA-1 hash of the download.
+// Format is the pack_format of pack.mcmeta, and MinFormat and MaxFormat the range of supported formats,
+// which are 0 if they are not given.
+type ResourcePackInfo struct {
+	Size        int64
+	Hash        string
+	Format      int
+	MinFormat   int
+	MaxFormat   int
+	Description Text
+}
+
+// DownloadResourcePack downloads the resource pack at url, hashes it, and reads its pack.mcmeta.
+//
+// Connections are made with Dialer and Resolver as for other requests, but without the PROXY protocol header,
+// and through the HTTP proxy configured by the environment, if any.
+// Packs larger than MaxResourcePackSize are not downloaded further.
+// The pack is stored in a temporary file, which is removed before returning.
+//
+// Size and Hash are set once the download is complete, even if it is not a valid pack and err is not nil.
+// If the pack is too large, Size is the number of bytes read and Hash is empty.
+func (c *Client) DownloadResourcePack(ctx context.Context, url string) (info ResourcePackInfo, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return
+	}
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:             http.ProxyFromEnvironment,
+		DialContext:       c.dialResolved,
+		ForceAttemptHTTP2: true,
+	}}
+	defer client.CloseIdleConnections()
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		err = errors.New("status not ok: " + resp.Status)
+		return
+	}
+
+	f, err := os.CreateTemp("", "minefetch-pack-*.zip")
+	if err != nil {
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	h := sha1.New()
+	n, err := io.Copy(io.MultiWriter(f, h), io.LimitReader(resp.Body, MaxResourcePackSize+1))
+	if err != nil {
+		return
+	}
+	info.Size = n
+	if info.Size > MaxResourcePackSize {
+		err = fmt.Errorf("larger than %v MiB", MaxResourcePackSize>>20)
+		return
+	}
+	info.Hash = hex.EncodeToString(h.Sum(nil))
+
+	zr, err := zip.NewReader(f, info.Size)
+	if err != nil {
+		return
+	}
+	mf, err := zr.Open("pack.mcmeta")
+	if err != nil {
+		return
+	}
+	defer mf.Close()
+	b, err := io.ReadAll(mf)
+	if err != nil {
+		return
+	}
+	err = parsePackMeta(b, &info)
+	return
+}
+
+// https://minecraft.wiki/w/Pack.mcmeta
+func parsePackMeta(b []byte, info *ResourcePackInfo) (err error) {
+	var meta struct {
+		Pack struct {
+			PackFormat       int `json:"pack_format"`
+			Description      Text
+			SupportedFormats json.RawMessage `json:"supported_formats"`
+			MinFormat        json.RawMessage `json:"min_format"`
+			MaxFormat        json.RawMessage `json:"max_format"`
+		}
+	}
+	// Some editors save a byte order mark
+	b = bytes.TrimPrefix(b, []byte("\ufeff"))
+	err = json.Unmarshal(b, &meta)
+	if err != nil {
+		return fmt.Errorf("failed to parse pack.mcmeta: %w", err)
+	}
+	info.Format = meta.Pack.PackFormat
+	info.Description = meta.Pack.Description
+
+	// Since 1.21.9, formats are a major version or a [major, minor] pair
+	if meta.Pack.MinFormat != nil || meta.Pack.MaxFormat != nil {
+		info.MinFormat = majorFormat(meta.Pack.MinFormat)
+		info.MaxFormat = majorFormat(meta.Pack.MaxFormat)
+		return
+	}
+
+	// Before, supported formats are a format, a [min, max] pair or an object
+	var format int
+	var formats []int
+	var object struct {
+		Min int `json:"min_inclusive"`
+		Max int `json:"max_inclusive"`
+	}
+	switch {
+	case meta.Pack.SupportedFormats == nil:
+	case json.Unmarshal(meta.Pack.SupportedFormats, &format) == nil:
+		info.MinFormat, info.MaxFormat = format, format
+	case json.Unmarshal(meta.Pack.SupportedFormats, &formats) == nil && len(formats) == 2:
+		info.MinFormat, info.MaxFormat = formats[0], formats[1]
+	case json.Unmarshal(meta.Pack.SupportedFormats, &object) == nil:
+		info.MinFormat, info.MaxFormat = object.Min, object.Max
+	}
+	return
+}
+
+// majorFormat returns the major version of a pack format, which is 0 if it is absent or invalid.
+func majorFormat(b json.RawMessage) int {
+	var format int
+	var formats []int
+	switch {
+	case json.Unmarshal(b, &format) == nil:
+		return format
+	case json.Unmarshal(b, &formats) == nil && len(formats) > 0:
+		return formats[0]
+	}
+	return 0
+}
@@ -0,0 +1,34 @@
+package mc
+
+import (
+	"bytes"
+	"testing"
+
+	"bhv.sh/minefetch/internal/wire"
+)
+
+func FuzzReadAddResourcePack(f *testing.F) {
+	for _, protocol := range []int32{764, 765} {
+		buf := &bytes.Buffer{}
+		if protocol >= nbtTextProtocol {
+			buf.Write(make([]byte, 16))
+		}
+		wire.WriteString(buf, "https://example.com/pack.zip")
+		wire.WriteString(buf, "da39a3ee5e6b4b0d3255bfef95601890afd80709")
+		buf.WriteByte(1)
+		buf.WriteByte(1)
+		if protocol >= nbtTextProtocol {
+			// "Required"
+			buf.WriteString("\x08\x00\x08Required")
+		} else {
+			wire.WriteString(buf, `{"text":"Required"}`)
+		}
+		f.Add(buf.Bytes(), protocol)
+	}
+	f.Fuzz(func(t *testing.T, b []byte, protocol int32) {
+		pack, err := readAddResourcePack(bytes.NewBuffer(b), protocol)
+		if err == nil {
+			pack.Prompt.Ansi()
+		}
+	})
+}
@@ -4,40 +4,42 @@ import (
 	"bytes"
 	"cmp"
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"io"
+
+	"bhv.sh/minefetch/internal/stage"
+	"bhv.sh/minefetch/internal/wire"
 )
 
 // https://minecraft.wiki/w/Java_Edition_protocol/Packets#Ping_Request_(status)
 func writePingRequest(w io.Writer, t int64) error {
 	buf := &bytes.Buffer{}
-	err1 := writeVarInt(buf, statusPacketIdPingRequest)
+	err1 := wire.WriteVarInt(buf, wire.StatusPacketIdPingRequest)
 	err2 := binary.Write(buf, binary.BigEndian, t)
 	if err := cmp.Or(err1, err2); err != nil {
 		return err
 	}
 
-	return writePacket(w, buf.Bytes())
+	return wire.WritePacket(w, buf.Bytes())
 }
 
 // https://minecraft.wiki/w/Java_Edition_protocol/Packets#Pong_Response_(status)
 func readPongResponse(r io.Reader, t0 int64) error {
-	id, buf, err := readPacket(r)
+	id, buf, err := wire.ReadPacket(r)
 	if err != nil {
 		return err
 	}
-	if id != statusPacketIdPongResponse {
-		return errors.New(fmt.Sprint("unexpected packet ID: ", id))
+	if id != wire.StatusPacketIdPongResponse {
+		return &stage.PacketIdError{Id: id}
 	}
 
 	var t1 int64
 	err = binary.Read(buf, binary.BigEndian, &t1)
 	if err != nil {
-		return errors.New("failed to read timestamp: " + err.Error())
+		return fmt.Errorf("failed to read timestamp: %w", err)
 	}
 	if t1 != t0 {
-		return errors.New(fmt.Sprint("unexpected timestamp: ", t1))
+		return fmt.Errorf("%w: unexpected timestamp: %v", stage.ErrProtocol, t1)
 	}
 
 	return nil
@@ -0,0 +1,158 @@
+package mc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// ProxyHeader configures the [PROXY protocol] header written at the start of every connection.
+//
+// Backends behind HAProxy, or proxies with proxy-protocol enabled, reject any connection
+// that does not begin with a PROXY header.
+//
+// A Version of 0 disables the header.
+// Version 1 is the human-readable format and is only sent on TCP connections.
+// Version 2 is the binary format and is also prepended to every UDP datagram.
+//
+// Source is the spoofed client address.
+// If it is not valid, the local address of the connection is used.
+//
+// [PROXY protocol]: https://www.haproxy.org/download/3.0/doc/proxy-protocol.txt
+type ProxyHeader struct {
+	Version int
+	Source  netip.AddrPort
+}
+
+var proxyV2Signature = [12]byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+const (
+	proxyV2Command byte = 0x21 // Version 2, PROXY command
+
+	proxyV2Inet  byte = 0x10
+	proxyV2Inet6 byte = 0x20
+	proxyV2Tcp   byte = 0x01
+	proxyV2Udp   byte = 0x02
+)
+
+// wrap writes the header at the start of conn if it is a TCP connection,
+// or returns conn wrapped to prepend the header to every datagram if it is a UDP connection.
+//
+// conn is returned as is if p is disabled, or if it is a UDP connection and p is version 1.
+func (p ProxyHeader) wrap(network string, conn net.Conn) (net.Conn, error) {
+	if p.Version == 0 {
+		return conn, nil
+	}
+
+	udp := network == "udp" || network == "udp4" || network == "udp6"
+	if udp && p.Version == 1 {
+		return conn, nil
+	}
+
+	header, err := p.header(udp, conn.LocalAddr(), conn.RemoteAddr())
+	if err != nil {
+		conn.Close()
+		return nil, errors.New("failed to build PROXY header: " + err.Error())
+	}
+
+	if udp {
+		return &proxyPacketConn{conn, header}, nil
+	}
+
+	_, err = conn.Write(header)
+	if err != nil {
+		conn.Close()
+		return nil, errors.New("failed to write PROXY header: " + err.Error())
+	}
+	return conn, nil
+}
+
+// proxyPacketConn prepends a PROXY protocol header to every datagram written.
+type proxyPacketConn struct {
+	net.Conn
+	header []byte
+}
+
+func (c *proxyPacketConn) Write(b []byte) (int, error) {
+	_, err := c.Conn.Write(append(c.header[:len(c.header):len(c.header)], b...))
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (p ProxyHeader) header(udp bool, local, remote net.Addr) ([]byte, error) {
+	src := p.Source
+	if !src.IsValid() {
+		src = addrPort(local)
+	}
+	dst := addrPort(remote)
+	if !src.IsValid() || !dst.IsValid() {
+		return nil, fmt.Errorf("unsupported addresses: %v, %v", local, remote)
+	}
+
+	src = netip.AddrPortFrom(src.Addr().Unmap(), src.Port())
+	dst = netip.AddrPortFrom(dst.Addr().Unmap(), dst.Port())
+	// Both addresses must belong to the same family
+	if src.Addr().Is4() != dst.Addr().Is4() {
+		src = netip.AddrPortFrom(netip.AddrFrom16(src.Addr().As16()), src.Port())
+		dst = netip.AddrPortFrom(netip.AddrFrom16(dst.Addr().As16()), dst.Port())
+	}
+
+	switch p.Version {
+	case 1:
+		return proxyV1Header(src, dst), nil
+	case 2:
+		return proxyV2Header(udp, src, dst), nil
+	}
+	return nil, fmt.Errorf("unsupported version: %v", p.Version)
+}
+
+// https://www.haproxy.org/download/3.0/doc/proxy-protocol.txt (2.1. Human-readable header format)
+func proxyV1Header(src, dst netip.AddrPort) []byte {
+	proto := "TCP4"
+	if !src.Addr().Is4() {
+		proto = "TCP6"
+	}
+	return fmt.Appendf(nil, "PROXY %v %v %v %v %v\r\n", proto, src.Addr(), dst.Addr(), src.Port(), dst.Port())
+}
+
+// https://www.haproxy.org/download/3.0/doc/proxy-protocol.txt (2.2. Binary header format)
+func proxyV2Header(udp bool, src, dst netip.AddrPort) []byte {
+	family := proxyV2Inet
+	if !src.Addr().Is4() {
+		family = proxyV2Inet6
+	}
+	if udp {
+		family |= proxyV2Udp
+	} else {
+		family |= proxyV2Tcp
+	}
+
+	addrs := &bytes.Buffer{}
+	addrs.Write(src.Addr().AsSlice())
+	addrs.Write(dst.Addr().AsSlice())
+	binary.Write(addrs, binary.BigEndian, src.Port())
+	binary.Write(addrs, binary.BigEndian, dst.Port())
+
+	buf := &bytes.Buffer{}
+	buf.Write(proxyV2Signature[:])
+	buf.WriteByte(proxyV2Command)
+	buf.WriteByte(family)
+	binary.Write(buf, binary.BigEndian, uint16(addrs.Len()))
+	buf.Write(addrs.Bytes())
+	return buf.Bytes()
+}
+
+func addrPort(addr net.Addr) netip.AddrPort {
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		return addr.AddrPort()
+	case *net.UDPAddr:
+		return addr.AddrPort()
+	}
+	return netip.AddrPort{}
+}
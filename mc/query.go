@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"cmp"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -11,21 +12,30 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"bhv.sh/minefetch/internal/stage"
+	"bhv.sh/minefetch/internal/wire"
 )
 
 // QueryResponse contains general server info provided by the [query protocol].
 //
+// Bedrock Edition servers implementing the protocol, such as PocketMine-MP and Nukkit,
+// set Game.Id to "MINECRAFTPE" and report a few extra keys.
+// Software is taken from server_engine if there is no plugins key,
+// and Whitelist is "on" or "off" if reported.
+//
 // [query protocol]: https://minecraft.wiki/w/Query
 type QueryResponse struct {
 	Motd string
 	Game struct {
 		Type, Id string
 	}
-	Version  string
-	Software string
-	Plugins  []string
-	World    string
-	Players  struct {
+	Version   string
+	Software  string
+	Plugins   []string
+	Whitelist string
+	World     string
+	Players   struct {
 		Max    int
 		Online int
 		Sample []string
@@ -44,63 +54,68 @@ type QueryResponse struct {
 // It is very similar to Status, but may contain more players and additional information about plugins.
 // However, the query protocol is not widely enabled by public servers.
 //
+// Query also works with Bedrock Edition servers that implement the protocol on their RakNet port,
+// in which case address should include the port.
+//
+// Both requests are resent as configured by Retry if they are not answered.
+// Latency is measured from the last handshake request sent.
+//
 // The query protocol encodes strings in ISO 8859-1.
 // Query will convert all strings to UTF-8 to support legacy formatting codes.
 //
 // [query protocol]: https://minecraft.wiki/w/Query
-func Query(address string) (query QueryResponse, err error) {
-	host, port := lookupHostPort(address, 25565)
-	query.Host = host
-	query.QueryPort = port
+func (c *Client) Query(ctx context.Context, address string) (query QueryResponse, err error) {
+	host, port := c.lookupHostPort(ctx, address, 25565)
 	address = JoinHostPort(host, port)
-	addr, _ := net.ResolveUDPAddr("udp", address)
 	start := time.Now()
 
-	conn, err := net.Dial("udp", addr.String())
+	conn, err := c.dial(ctx, "udp", address)
 	if err != nil {
+		err = stage.Wrap(stage.Connect, err)
 		return
 	}
+	conn = c.traced(conn, wire.ProtocolQuery)
 	defer conn.Close()
+	deadline := c.deadline(ctx)
+	conn.SetDeadline(deadline)
 
 	id := int32(time.Now().Unix()) & 0x0f0f0f0f
-	err = writeQueryHandshake(conn, id)
-	if err != nil {
+	var token int32
+	err = c.Retry.Exchange(conn, deadline, func() error {
+		start = time.Now()
+		return writeQueryHandshake(conn, id)
+	}, func() (err error) {
+		token, err = readQueryHandshake(conn, id)
 		return
-	}
-
-	token, err := readQueryHandshake(conn, id)
+	})
 	if err != nil {
+		err = stage.Wrap(stage.QueryHandshake, err)
 		return
 	}
 
-	query.Latency = time.Since(start)
+	latency := time.Since(start)
 
-	err = writeQueryStatus(conn, id, token)
-	if err != nil {
+	err = c.Retry.Exchange(conn, deadline, func() error {
+		return writeQueryStatus(conn, id, token)
+	}, func() (err error) {
+		query, err = readQueryStatus(conn, id)
 		return
-	}
-
-	query, err = readQueryStatus(conn, id)
+	})
 	if err != nil {
+		err = stage.Wrap(stage.QueryStatus, err)
 		return
 	}
+	query.Host = host
+	query.QueryPort = port
+	query.Latency = latency
 
 	return
 }
 
-const queryMagic uint16 = 0xFEFD
-
-type queryPacketType byte
-
-const (
-	queryPacketTypeHandshake queryPacketType = 9
-	queryPacketTypeStat      queryPacketType = 0
-)
-
 // https://minecraft.wiki/w/Query#Client_to_Server_Packet_Format
-func writeQueryPacket(w io.Writer, t queryPacketType, id int32, payload ...any) (err error) {
+func writeQueryPacket(w io.Writer, t wire.QueryPacketType, id int32, payload ...any) (err error) {
 	buf := &bytes.Buffer{}
-	err1 := binary.Write(buf, binary.BigEndian, queryMagic)
+	err1 := binary.Write(buf, binary.BigEndian, wire.QueryMagic)
 	err2 := binary.Write(buf, binary.BigEndian, t)
 	err3 := binary.Write(buf, binary.BigEndian, id)
 	if payload[0] != nil {
@@ -118,17 +133,17 @@ func writeQueryPacket(w io.Writer, t queryPacketType, id int32, payload ...any)
 
 // https://minecraft.wiki/w/Query#Request
 func writeQueryHandshake(w io.Writer, id int32) error {
-	return writeQueryPacket(w, queryPacketTypeHandshake, id, nil)
+	return writeQueryPacket(w, wire.QueryPacketTypeHandshake, id, nil)
 }
 
 // https://minecraft.wiki/w/Query#Request_3
 func writeQueryStatus(w io.Writer, id, token int32) error {
-	return writeQueryPacket(w, queryPacketTypeStat, id, token, int32(0))
+	return writeQueryPacket(w, wire.QueryPacketTypeStat, id, token, int32(0))
 }
 
 // https://minecraft.wiki/w/Query#Server_to_Client_Packet_Format
-func readQueryPacketHeader(r *bufio.Reader, t queryPacketType, id int32) (err error) {
-	var st queryPacketType
+func readQueryPacketHeader(r *bufio.Reader, t wire.QueryPacketType, id int32) (err error) {
+	var st wire.QueryPacketType
 	var sid int32
 
 	err1 := binary.Read(r, binary.BigEndian, &st)
@@ -137,11 +152,11 @@ func readQueryPacketHeader(r *bufio.Reader, t queryPacketType, id int32) (err er
 		return
 	}
 	if st != t {
-		err = fmt.Errorf("expected packet type %v, got type: %v", t, st)
+		err = fmt.Errorf("%w: expected packet type %v, got type: %v", stage.ErrProtocol, t, st)
 		return
 	}
 	if sid != id {
-		err = fmt.Errorf("expected session id %v, got: %v", id, sid)
+		err = fmt.Errorf("%w: expected session id %v, got: %v", stage.ErrProtocol, id, sid)
 		return
 	}
 
@@ -152,7 +167,7 @@ func readQueryPacketHeader(r *bufio.Reader, t queryPacketType, id int32) (err er
 func readQueryHandshake(r io.Reader, id int32) (token int32, err error) {
 	br := bufio.NewReader(r)
 
-	err = readQueryPacketHeader(br, queryPacketTypeHandshake, id)
+	err = readQueryPacketHeader(br, wire.QueryPacketTypeHandshake, id)
 	if err != nil {
 		return
 	}
@@ -175,7 +190,7 @@ func readQueryHandshake(r io.Reader, id int32) (token int32, err error) {
 func readQueryStatus(r io.Reader, id int32) (query QueryResponse, err error) {
 	br := bufio.NewReader(r)
 
-	err = readQueryPacketHeader(br, queryPacketTypeStat, id)
+	err = readQueryPacketHeader(br, wire.QueryPacketTypeStat, id)
 	if err != nil {
 		return
 	}
@@ -194,7 +209,14 @@ func readQueryStatus(r io.Reader, id int32) (query QueryResponse, err error) {
 	if err != nil {
 		return
 	}
-	query.Raw = string(b)
+	return ParseQuery(string(b))
+}
+
+// ParseQuery parses the key-value and player sections of a full stat response, such as a saved Raw field.
+// Host, QueryPort and Latency are not set.
+func ParseQuery(s string) (query QueryResponse, err error) {
+	br := bufio.NewReader(strings.NewReader(s))
+	query.Raw = s
 
 	for {
 		var k string
@@ -230,7 +252,16 @@ func readQueryStatus(r io.Reader, id int32) (query QueryResponse, err error) {
 			query.Game.Id = v
 		case "version":
 			query.Version = v
+		case "server_engine":
+			if query.Software == "" {
+				query.Software = v
+			}
+		case "whitelist":
+			query.Whitelist = v
 		case "plugins":
+			if v == "" {
+				break
+			}
 			i := strings.Index(v, ": ")
 			if i == -1 {
 				query.Software = v
@@ -265,7 +296,7 @@ func readQueryStatus(r io.Reader, id int32) (query QueryResponse, err error) {
 	}
 
 	// Padding
-	n, err = br.Discard(10)
+	n, err := br.Discard(10)
 	if err != nil {
 		return
 	}
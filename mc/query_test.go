@@ -0,0 +1,46 @@
+package mc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"bhv.sh/minefetch/internal/wire"
+)
+
+const queryStat = "hostname\x00A Minecraft Server\x00gametype\x00SMP\x00game_id\x00MINECRAFT\x00version\x001.21.4\x00" +
+	"plugins\x00Paper on 1.21.4: WorldEdit 7.3.9; LuckPerms 5.4\x00map\x00world\x00numplayers\x002\x00maxplayers\x0020\x00" +
+	"hostport\x0025565\x00hostip\x00127.0.0.1\x00\x00\x01player_\x00\x00Notch\x00jeb_\x00\x00"
+
+func FuzzReadQueryHandshake(f *testing.F) {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.BigEndian, wire.QueryPacketTypeHandshake)
+	binary.Write(buf, binary.BigEndian, int32(1))
+	buf.WriteString("9513307\x00")
+	f.Add(buf.Bytes())
+	f.Fuzz(func(t *testing.T, b []byte) {
+		readQueryHandshake(bytes.NewReader(b), 1)
+	})
+}
+
+func FuzzReadQueryStatus(f *testing.F) {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.BigEndian, wire.QueryPacketTypeStat)
+	binary.Write(buf, binary.BigEndian, int32(1))
+	buf.WriteString("splitnum\x00\x80\x00")
+	buf.WriteString(queryStat)
+	f.Add(buf.Bytes())
+	f.Fuzz(func(t *testing.T, b []byte) {
+		readQueryStatus(bytes.NewReader(b), 1)
+	})
+}
+
+func FuzzParseQuery(f *testing.F) {
+	f.Add(queryStat)
+	f.Fuzz(func(t *testing.T, s string) {
+		query, err := ParseQuery(s)
+		if err == nil && query.Raw != s {
+			t.Errorf("Raw is %q, want %q", query.Raw, s)
+		}
+	})
+}
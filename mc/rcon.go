@@ -0,0 +1,47 @@
+package mc
+
+import (
+	"context"
+	"time"
+
+	"bhv.sh/minefetch/internal/stage"
+	"bhv.sh/minefetch/internal/wire"
+)
+
+// RCON reports whether the [remote console] (RCON) is enabled on the server at address.
+//
+// An empty-password login request is made,
+// and the validity of the response is used to determine whether RCON is enabled or not.
+//
+// [remote console]: https://minecraft.wiki/w/RCON
+func (c *Client) RCON(ctx context.Context, address string) (enabled bool, err error) {
+	_, argPort, err := SplitHostPort(address)
+	host, port := c.lookupHostPort(ctx, address, 25575)
+	if err == nil {
+		port = argPort
+	}
+	address = JoinHostPort(host, port)
+	conn, err := c.dial(ctx, "tcp", address)
+	if err != nil {
+		err = stage.Wrap(stage.Connect, err)
+		return
+	}
+	conn = c.traced(conn, wire.ProtocolRcon)
+	defer conn.Close()
+	conn.SetDeadline(c.deadline(ctx))
+
+	err = wire.WriteRconPacket(conn, int32(time.Now().Unix()), wire.RconPacketTypeLoginRequest, "")
+	if err != nil {
+		err = stage.Wrap(stage.RconLogin, err)
+		return
+	}
+
+	_, _, _, err = wire.ReadRconPacket(conn)
+	if err != nil {
+		err = stage.Wrap(stage.RconLogin, err)
+		return
+	}
+
+	enabled = true
+	return
+}
@@ -0,0 +1,326 @@
+package mc
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"bhv.sh/minefetch/internal/stage"
+	"bhv.sh/minefetch/internal/wire"
+)
+
+// StatusResponse contains general server info provided by the [Server List Ping interface].
+//
+// Any string field except Icon and Uuid may contain legacy formatting.
+//
+// Version.Name may contain any third-party server software name.
+// This field is only visible on vanilla clients when the reported Protocol is incompatible.
+// Some servers use this field along with an invalid Protocol to display arbitrary information.
+//
+// Sample is sometimes used to display arbitrary information.
+//
+// PreventsChatReports is sent by servers using plugins or mods like [No Chat Reports].
+//
+// Icon is the raw encoded PNG data.
+//
+// Latencies holds the round-trip time of each ping sent by StatusPing, with lost pings stored as 0.
+// PingErr is the error of the ping sent by Status, in which case Latency is 0.
+//
+// [Server List Ping interface]: https://minecraft.wiki/w/Java_Edition_protocol/Server_List_Ping
+// [No Chat Reports]: https://github.com/Aizistral-Studios/No-Chat-Reports/wiki/How-to-Get-Safe-Server-Status
+type StatusResponse struct {
+	Version struct {
+		Name     string
+		Protocol int32
+	}
+	EnforcesSecureChat bool
+	Motd               Text `json:"description"`
+	Players            struct {
+		Max    int
+		Online int
+		Sample []struct {
+			Uuid string `json:"id"`
+			Name string
+		}
+	}
+	Icon                Icon `json:"favicon"`
+	PreventsChatReports bool
+	Forge               struct {
+		Version uint8
+		Mods    []mod
+	}
+
+	Host      string
+	Port      uint16
+	Latency   time.Duration
+	Latencies []time.Duration
+	PingErr   error `json:"-"`
+	Raw       string
+}
+
+type mod struct {
+	Name    string
+	Version string
+}
+
+type statusResponse struct {
+	StatusResponse
+	ForgeData struct {
+		Channels []struct {
+			Res      string
+			Version  string
+			Required bool
+		}
+		Mods []struct {
+			ModId     string
+			ModMarker string
+		}
+		FmlNetworkVersion int
+		D                 string
+	}
+	ModInfo struct {
+		Type    string
+		ModList []struct {
+			ModId   string
+			Version string
+		}
+	}
+}
+
+// Status attempts to get general server info using the [Server List Ping interface].
+//
+// This is the same interface used by the in-game server list.
+//
+// Most MOTD plugins will fall back to legacy formatting codes for proto versions before 1.16.
+//
+// It is possible for servers to disable the Server List Ping interface,
+// so no response does not necessarily mean a server is offline.
+//
+// Some servers only respond to a second request.
+// This may be a countermeasure against server scanners like [Copenheimer].
+//
+// [Server List Ping interface]: https://minecraft.wiki/w/Java_Edition_protocol/Server_List_Ping
+// [Copenheimer]: https://2b2t.miraheze.org/wiki/Fifth_Column#Copenheimer
+func (c *Client) Status(ctx context.Context, address string) (status StatusResponse, err error) {
+	return c.StatusPing(ctx, address, 1, 0)
+}
+
+// StatusPing is like Status, but sends count ping requests interval apart on the same connection.
+//
+// Many servers close the connection after the first ping,
+// in which case a new connection is opened for each following ping.
+// A ping is lost if no pong is received within Timeout, or if reconnecting fails.
+//
+// Latency is the average round-trip time of all pings that were not lost.
+// If count is 1, a lost ping is reported in PingErr instead.
+func (c *Client) StatusPing(ctx context.Context, address string, count int, interval time.Duration) (status StatusResponse, err error) {
+	host, port := c.lookupHostPort(ctx, address, 25565)
+
+	address = JoinHostPort(host, port)
+	conn, err := c.dial(ctx, "tcp", address)
+	if err != nil {
+		err = stage.Wrap(stage.Connect, err)
+		return
+	}
+	conn = c.traced(conn, wire.ProtocolJava)
+	defer conn.Close()
+	conn.SetDeadline(c.deadline(ctx))
+
+	hsHost, hsPort := c.handshakeAddr(host, port)
+	err1 := writeHandshake(conn, c.protocol(), hsHost, hsPort, intentStatus)
+	err2 := writeStatusRequest(conn)
+	if err = cmp.Or(err1, err2); err != nil {
+		err = stage.Wrap(stage.Handshake, err)
+		return
+	}
+
+	status, err = readStatusResponse(conn)
+	if err != nil {
+		err = stage.Wrap(stage.StatusResponse, err)
+		return
+	}
+	status.Host = host
+	status.Port = port
+
+	conn.SetDeadline(c.deadline(ctx))
+	start := time.Now()
+	err = writePingRequest(conn, start.Unix())
+	if err != nil {
+		err = stage.Wrap(stage.Ping, err)
+		return
+	}
+
+	if count <= 1 {
+		pingErr := readPongResponse(conn, start.Unix())
+		if pingErr != nil {
+			status.PingErr = stage.Wrap(stage.Ping, pingErr)
+			return
+		}
+		status.Latency = time.Since(start)
+		return
+	}
+
+	pong := readPongResponse(conn, start.Unix())
+	status.Latencies = make([]time.Duration, 0, count)
+	status.Latencies = append(status.Latencies, time.Since(start))
+	if pong != nil {
+		status.Latencies[0] = 0
+		conn.Close()
+		conn = nil
+	}
+
+	for range count - 1 {
+		select {
+		case <-time.After(interval - time.Since(start)):
+		case <-ctx.Done():
+			return status, stage.Wrap(stage.Ping, ctx.Err())
+		}
+		start = time.Now()
+		var latency time.Duration
+		latency, conn = c.ping(ctx, conn, address, host, port)
+		status.Latencies = append(status.Latencies, latency)
+	}
+	if conn != nil {
+		conn.Close()
+	}
+
+	var sum time.Duration
+	var n int
+	for _, l := range status.Latencies {
+		if l != 0 {
+			sum += l
+			n++
+		}
+	}
+	if n != 0 {
+		status.Latency = sum / time.Duration(n)
+	}
+
+	return
+}
+
+// ping sends a single ping request on conn, or on a new connection if conn is nil.
+// If the ping fails on conn, it is retried once on a new connection,
+// as the server may have closed conn after the previous pong.
+//
+// It returns the round-trip time, or 0 if the ping was lost,
+// and the connection to use for the next ping, which is nil if the connection failed.
+func (c *Client) ping(ctx context.Context, conn net.Conn, address string, host string, port uint16) (time.Duration, net.Conn) {
+	if conn != nil {
+		latency, conn := c.pingOnce(ctx, conn, address, host, port)
+		if conn != nil {
+			return latency, conn
+		}
+	}
+	return c.pingOnce(ctx, nil, address, host, port)
+}
+
+func (c *Client) pingOnce(ctx context.Context, conn net.Conn, address string, host string, port uint16) (time.Duration, net.Conn) {
+	if conn == nil {
+		var err error
+		conn, err = c.dial(ctx, "tcp", address)
+		if err != nil {
+			return 0, nil
+		}
+		conn = c.traced(conn, wire.ProtocolJava)
+		hsHost, hsPort := c.handshakeAddr(host, port)
+		err = writeHandshake(conn, c.protocol(), hsHost, hsPort, intentStatus)
+		if err != nil {
+			conn.Close()
+			return 0, nil
+		}
+	}
+	conn.SetDeadline(c.deadline(ctx))
+
+	start := time.Now()
+	t := start.UnixNano()
+	err1 := writePingRequest(conn, t)
+	err2 := readPongResponse(conn, t)
+	if cmp.Or(err1, err2) != nil {
+		conn.Close()
+		return 0, nil
+	}
+	return time.Since(start), conn
+}
+
+type Icon []byte
+
+func (icon *Icon) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		return nil
+	}
+	text, ok := bytes.CutPrefix(text, []byte("data:image/png;base64,"))
+	if !ok {
+		return fmt.Errorf("%w: icon is not a PNG data URL", stage.ErrProtocol)
+	}
+	*icon = make([]byte, base64.StdEncoding.DecodedLen(len(text)))
+	n, err := base64.StdEncoding.Decode(*icon, text)
+	*icon = (*icon)[:n]
+	return err
+}
+
+// https://minecraft.wiki/w/Java_Edition_protocol/Server_List_Ping#Status_Request
+func writeStatusRequest(w io.Writer) error {
+	buf := &bytes.Buffer{}
+	err := wire.WriteVarInt(buf, wire.StatusPacketIdStatusRequest)
+	if err != nil {
+		return err
+	}
+
+	return wire.WritePacket(w, buf.Bytes())
+}
+
+// https://minecraft.wiki/w/Java_Edition_protocol/Server_List_Ping#Status_Response
+func readStatusResponse(r io.Reader) (status StatusResponse, err error) {
+	id, buf, err := wire.ReadPacket(r)
+	if err != nil {
+		return
+	}
+	if id != wire.StatusPacketIdStatusResponse {
+		err = &stage.PacketIdError{Id: id}
+		return
+	}
+
+	s, err := wire.ReadString(buf, wire.MaxStringLength)
+	if err != nil {
+		err = fmt.Errorf("failed to read string: %w", err)
+		return
+	}
+	return ParseStatus(s)
+}
+
+// ParseStatus parses the JSON of a status response, such as a saved Raw field.
+// Host, Port and the latencies are not set.
+func ParseStatus(s string) (status StatusResponse, err error) {
+	var raw statusResponse
+	raw.Raw = s
+
+	err = json.Unmarshal([]byte(s), &raw)
+	if err != nil {
+		err = fmt.Errorf("failed to parse JSON: %w", err)
+		return
+	}
+
+	if len(raw.ForgeData.Mods) > 0 {
+		raw.Forge.Version = uint8(raw.ForgeData.FmlNetworkVersion)
+		raw.Forge.Mods = make([]mod, 0, len(raw.ForgeData.Mods))
+		for _, m := range raw.ForgeData.Mods {
+			raw.Forge.Mods = append(raw.Forge.Mods, mod{m.ModId, m.ModMarker})
+		}
+	} else if len(raw.ModInfo.ModList) > 0 {
+		raw.Forge.Version = 1
+		raw.Forge.Mods = make([]mod, 0, len(raw.ModInfo.ModList))
+		for _, m := range raw.ModInfo.ModList {
+			raw.Forge.Mods = append(raw.Forge.Mods, mod{m.ModId, m.Version})
+		}
+	}
+
+	status = raw.StatusResponse
+	return
+}
@@ -85,6 +85,11 @@ func normText(v any, parent Text) Text {
 		t.Extra = []Text{}
 		return t
 	case []any:
+		if len(v) == 0 {
+			t := parent
+			t.Extra = []Text{}
+			return t
+		}
 		t := normText(v[0], parent)
 		for _, e := range v[1:] {
 			t.Extra = append(t.Extra, normText(e, t))
@@ -128,11 +133,13 @@ func normText(v any, parent Text) Text {
 
 // LegacyTextAnsi converts [Minecraft legacy formatting] to ANSI escape codes.
 //
+// Control characters and escape sequences in s are removed, see term.Sanitize.
+//
 // [Minecraft legacy formatting]: https://minecraft.wiki/w/Formatting_codes
 func LegacyTextAnsi(s string) string {
 	var b strings.Builder
 	esc := false
-	for _, v := range s {
+	for _, v := range term.Sanitize(s) {
 		if !esc {
 			if v == '§' {
 				esc = true
@@ -168,6 +175,24 @@ func LegacyTextAnsi(s string) string {
 	return b.String()
 }
 
+// LegacyTextPlain removes [Minecraft legacy formatting] codes from s.
+//
+// [Minecraft legacy formatting]: https://minecraft.wiki/w/Formatting_codes
+func LegacyTextPlain(s string) string {
+	var b strings.Builder
+	esc := false
+	for _, v := range s {
+		if esc {
+			esc = false
+		} else if v == '§' {
+			esc = true
+		} else {
+			b.WriteRune(v)
+		}
+	}
+	return b.String()
+}
+
 // Colors corresponding to legacy formatting color codes and the server list default text color.
 var (
 	Default     = color.NRGBA{128, 128, 128, 255}
@@ -0,0 +1,88 @@
+package mcpe
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"bhv.sh/minefetch/internal/netctx"
+	"bhv.sh/minefetch/internal/stage"
+)
+
+// Diagnose runs the same steps as Status one at a time,
+// recording the outcome and duration of each.
+//
+// Connections are made with d, and domains are resolved with r, or net.DefaultResolver if it is nil.
+// Each step that waits on the server is given timeout to complete.
+// Steps following a failed step are not run.
+func Diagnose(ctx context.Context, d Dialer, r Resolver, address string, timeout time.Duration) (results []stage.Result) {
+	if r == nil {
+		r = net.DefaultResolver
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, "19132"
+	}
+	ip := net.ParseIP(host)
+
+	if ip == nil {
+		ok := stage.Run(&results, stage.Lookup, func() (string, error) {
+			ips, err := r.LookupIPAddr(ctx, host)
+			if err != nil {
+				return "", err
+			}
+			if len(ips) == 0 {
+				return "", &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+			}
+			ip = ips[0].IP
+			ss := make([]string, len(ips))
+			for i, ip := range ips {
+				ss[i] = ip.String()
+			}
+			return strings.Join(ss, ", "), nil
+		})
+		if !ok {
+			return
+		}
+	}
+
+	var conn *datagramConn
+	ok := stage.Run(&results, stage.Connect, func() (string, error) {
+		c, err := dial(ctx, d, net.JoinHostPort(ip.String(), port))
+		if err != nil {
+			return "", err
+		}
+		conn = &datagramConn{Conn: c}
+		return conn.RemoteAddr().String(), nil
+	})
+	if !ok {
+		return
+	}
+	defer conn.Close()
+
+	stage.Run(&results, stage.Pong, func() (string, error) {
+		conn.SetDeadline(netctx.Deadline(ctx, timeout))
+		t := time.Now().UnixMilli()
+		err := writeUnconnectedPing(conn, t)
+		if err != nil {
+			return "", err
+		}
+		_, err = readMatchingPong(conn, t, t)
+		return fmt.Sprint(conn.n, " bytes"), err
+	})
+	return
+}
+
+// datagramConn records the size of the last datagram read.
+type datagramConn struct {
+	net.Conn
+	n int
+}
+
+func (c *datagramConn) Read(p []byte) (n int, err error) {
+	n, err = c.Conn.Read(p)
+	c.n = n
+	return
+}
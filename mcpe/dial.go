@@ -0,0 +1,82 @@
+package mcpe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+
+	"bhv.sh/minefetch/internal/netctx"
+	"bhv.sh/minefetch/internal/retry"
+	"bhv.sh/minefetch/internal/stage"
+)
+
+// Retry controls how often an unanswered request is resent.
+//
+// Count is the number of times a request is resent if no response arrives.
+// The first retry is sent Backoff after the request, 250 ms by default,
+// and the wait doubles after each retry.
+// No retries are sent after the timeout.
+type Retry = retry.Policy
+
+// Dialer connects to servers. It is implemented by *net.Dialer.
+//
+// Functions taking a Dialer use a zero net.Dialer if it is nil.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// Resolver looks up host addresses. It is implemented by *net.Resolver.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// dial connects to address over UDP using d.
+// The connection is closed when ctx is done.
+func dial(ctx context.Context, d Dialer, address string) (net.Conn, error) {
+	if d == nil {
+		d = &net.Dialer{}
+	}
+	conn, err := d.DialContext(ctx, "udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return netctx.CloseOnDone(ctx, conn), nil
+}
+
+// maxDatagramSize is the largest UDP payload.
+const maxDatagramSize = 65535
+
+// errTruncated is returned when a packet ends before all of its fields.
+var errTruncated = fmt.Errorf("%w: truncated packet", stage.ErrProtocol)
+
+// readDatagram reads a single datagram from r to decode a packet from.
+//
+// A packet never continues into the next datagram,
+// so reading past the end of the datagram returns errTruncated instead of io.EOF.
+func readDatagram(r io.Reader) (datagram, error) {
+	b := make([]byte, maxDatagramSize)
+	n, err := r.Read(b)
+	return datagram{bytes.NewReader(b[:n])}, err
+}
+
+type datagram struct {
+	*bytes.Reader
+}
+
+func (d datagram) Read(p []byte) (n int, err error) {
+	n, err = d.Reader.Read(p)
+	if err == io.EOF {
+		err = errTruncated
+	}
+	return
+}
+
+func (d datagram) ReadByte() (b byte, err error) {
+	b, err = d.Reader.ReadByte()
+	if err == io.EOF {
+		err = errTruncated
+	}
+	return
+}
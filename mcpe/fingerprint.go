@@ -0,0 +1,148 @@
+package mcpe
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Server software recognized by Fingerprint.
+const (
+	SoftwareBDS         = "Bedrock Dedicated Server"
+	SoftwarePocketMine  = "PocketMine-MP"
+	SoftwareNukkit      = "Nukkit"
+	SoftwarePowerNukkit = "PowerNukkit"
+	SoftwareGeyser      = "Geyser"
+	SoftwareWaterdog    = "WaterdogPE"
+)
+
+// Confidence is how likely a Fingerprint guess is to be right.
+type Confidence int
+
+const (
+	Low Confidence = iota
+	Medium
+	High
+)
+
+func (c Confidence) String() string {
+	switch c {
+	case High:
+		return "high"
+	case Medium:
+		return "medium"
+	}
+	return "low"
+}
+
+// Software is the likely software running a Bedrock server,
+// along with the quirks that gave it away.
+type Software struct {
+	Name       string
+	Confidence Confidence
+	Reasons    []string
+}
+
+// Fingerprint guesses the software running a Bedrock server
+// from the quirks of its unconnected pong and, if probe is not nil, its RakNet handshake.
+//
+// The pong string is mostly configurable, so the guess relies on defaults
+// that are often left unchanged, such as the level name.
+// ok is false if nothing points to a known software.
+func Fingerprint(status StatusResponse, probe *ProbeResponse) (sw Software, ok bool) {
+	scores := make(map[string]int)
+	reasons := make(map[string][]string)
+	add := func(name string, score int, reason string) {
+		scores[name] += score
+		reasons[name] = append(reasons[name], reason)
+	}
+
+	level := strings.ToLower(status.Level)
+	switch {
+	case level == "bedrock level":
+		add(SoftwareBDS, 3, "default level name")
+	case level == "pocketmine-mp":
+		add(SoftwarePocketMine, 4, "default sub-MOTD")
+	case strings.Contains(level, "powernukkit"):
+		add(SoftwarePowerNukkit, 4, "default sub-MOTD")
+	case strings.Contains(level, "nukkit"):
+		add(SoftwareNukkit, 4, "default sub-MOTD")
+	case level == "geyser" || level == "another geyser server.":
+		add(SoftwareGeyser, 4, "default sub-MOTD")
+	case strings.Contains(level, "waterdog"):
+		add(SoftwareWaterdog, 4, "default sub-MOTD")
+	}
+
+	name := strings.ToLower(status.Name)
+	switch {
+	case name == "dedicated server":
+		add(SoftwareBDS, 2, "default server name")
+	case name == "geyser":
+		add(SoftwareGeyser, 2, "default MOTD")
+	case strings.Contains(name, "waterdog"):
+		add(SoftwareWaterdog, 2, "default MOTD")
+	}
+
+	// BDS appends a field after the ports; the others stop at the IPv6 port
+	fields := strings.Split(strings.TrimSuffix(status.Raw, ";"), ";")
+	switch len(fields) {
+	case 13:
+		add(SoftwareBDS, 2, "13 pong fields")
+	case 12:
+		for _, name := range []string{SoftwarePocketMine, SoftwareNukkit, SoftwarePowerNukkit, SoftwareGeyser, SoftwareWaterdog} {
+			add(name, 1, "12 pong fields")
+		}
+	}
+
+	// The GUID is written twice by every known implementation
+	guidMismatch := status.ID != "" && status.ID != strconv.FormatInt(status.GUID, 10) && status.ID != strconv.FormatUint(uint64(status.GUID), 10)
+	if guidMismatch {
+		add(SoftwareWaterdog, 1, "pong GUID differs from the advertised ID")
+		add(SoftwareGeyser, 1, "pong GUID differs from the advertised ID")
+	}
+
+	if probe != nil {
+		// Proxies forwarding the pong from a backend answer pings slower than they handshake
+		if status.Latency > 2*probe.Latency+10*time.Millisecond {
+			add(SoftwareGeyser, 2, "pong is slower than the handshake")
+			add(SoftwareWaterdog, 2, "pong is slower than the handshake")
+		}
+		// The Netty RakNet transport caps the MTU at 1400
+		switch {
+		case probe.MTU == 1400:
+			for _, name := range []string{SoftwareGeyser, SoftwareWaterdog, SoftwareNukkit, SoftwarePowerNukkit} {
+				add(name, 1, "MTU capped at 1400")
+			}
+		case probe.MTU > 1400:
+			for _, name := range []string{SoftwareBDS, SoftwarePocketMine} {
+				add(name, 1, "MTU above 1400")
+			}
+		}
+		if probe.GUID != status.GUID {
+			add(SoftwareWaterdog, 1, "handshake GUID differs from the pong")
+		}
+	}
+
+	var best, second int
+	for name, score := range scores {
+		if score > best {
+			second = best
+			best = score
+			sw.Name = name
+		} else {
+			second = max(second, score)
+		}
+	}
+	// Ties are not broken, as either guess would be arbitrary
+	if best == 0 || best == second {
+		return Software{}, false
+	}
+	sw.Reasons = reasons[sw.Name]
+	switch margin := best - second; {
+	case best >= 5 && margin >= 3:
+		sw.Confidence = High
+	case best >= 3 && margin >= 2:
+		sw.Confidence = Medium
+	}
+	return sw, true
+}
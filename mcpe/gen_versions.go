@@ -0,0 +1,102 @@
+//go:build ignore
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/format"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	var client http.Client
+	req, err := http.NewRequest("GET", "https://minecraft.wiki/rest.php/v1/page/Module:Protocol_version%2FVersions", nil)
+	if err != nil {
+		panic(err)
+	}
+	// TODO: why is this required to avoid a 403 response?
+	req.Header.Set("User-Agent", "Go-http-client/1.1")
+	res, err := client.Do(req)
+	if err != nil {
+		panic(err)
+	}
+
+	m := make(map[int][]string)
+	re := regexp.MustCompile(`verBE\s*\(\s*bedrock\s*,\s*'([^']+)'\s*,\s*(\d+),`)
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Split(scanEscapedLines)
+	for scanner.Scan() {
+		s := scanner.Text()
+		matches := re.FindStringSubmatch(s)
+		if len(matches) > 0 {
+			name := matches[1]
+			// Previews and betas share protocol numbers with releases
+			if strings.ContainsAny(name, " ") {
+				continue
+			}
+			id, err := strconv.Atoi(matches[2])
+			if err != nil {
+				panic(err)
+			}
+			m[id] = append(m[id], name)
+		}
+	}
+
+	ids := make([]int, 0, len(m))
+	for k := range m {
+		ids = append(ids, k)
+	}
+	slices.Sort(ids)
+
+	var b strings.Builder
+	b.WriteString("// Code generated by \"go run gen_versions.go\". DO NOT EDIT.\n\npackage mcpe\n\n")
+
+	b.WriteString("var versionIdName = map[int]string{\n")
+	for _, id := range ids {
+		if len(m[id]) == 1 {
+			fmt.Fprintf(&b, "\t%d: \"%s\",\n", id, m[id][0])
+		} else {
+			first := m[id][len(m[id])-1]
+			last := m[id][0]
+			fmt.Fprintf(&b, "\t%d: \"%s – %s\",\n", id, first, last)
+		}
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("var versionNameId = map[string]int{\n")
+	for _, id := range ids {
+		for i := len(m[id]) - 1; i >= 0; i-- {
+			fmt.Fprintf(&b, "\t\"%s\": %d,\n", m[id][i], id)
+		}
+	}
+	b.WriteString("}\n")
+
+	formatted, err := format.Source([]byte(b.String()))
+	if err != nil {
+		panic(err)
+	}
+	err = os.WriteFile("versions_gen.go", formatted, 0644)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func scanEscapedLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	escaped := false
+	for i := range data {
+		if escaped && data[i] == 'n' {
+			return i + 1, data[0 : i-1], nil
+		}
+		escaped = data[i] == '\\'
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
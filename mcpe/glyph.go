@@ -0,0 +1,134 @@
+package mcpe
+
+import "strings"
+
+// Private use area range in which Bedrock Edition draws glyphs from its glyph_E0 and glyph_E1 sheets.
+const (
+	glyphFirst rune = 0xE000
+	glyphLast  rune = 0xE1FF
+)
+
+type glyph struct {
+	name     string
+	fallback string
+}
+
+// Known glyphs, mostly controller buttons and HUD icons.
+var glyphs = map[rune]glyph{
+	// Xbox
+	0xE000: {"xbox_a", "Ⓐ"},
+	0xE001: {"xbox_b", "Ⓑ"},
+	0xE002: {"xbox_x", "Ⓧ"},
+	0xE003: {"xbox_y", "Ⓨ"},
+	0xE004: {"xbox_lb", "LB"},
+	0xE005: {"xbox_rb", "RB"},
+	0xE006: {"xbox_lt", "LT"},
+	0xE007: {"xbox_rt", "RT"},
+	0xE008: {"xbox_view", "⧉"},
+	0xE009: {"xbox_menu", "☰"},
+	0xE00A: {"xbox_ls", "Ⓛ"},
+	0xE00B: {"xbox_rs", "Ⓡ"},
+	0xE00C: {"xbox_dpad_up", "↑"},
+	0xE00D: {"xbox_dpad_left", "←"},
+	0xE00E: {"xbox_dpad_down", "↓"},
+	0xE00F: {"xbox_dpad_right", "→"},
+
+	// Touch
+	0xE010: {"touch_jump", "⤒"},
+	0xE011: {"touch_attack", "⚔"},
+	0xE012: {"touch_up", "↑"},
+	0xE013: {"touch_left", "←"},
+	0xE014: {"touch_down", "↓"},
+	0xE015: {"touch_right", "→"},
+	0xE016: {"touch_sneak", "⤓"},
+	0xE017: {"touch_use", "✋"},
+	0xE018: {"touch_fly_up", "⇑"},
+	0xE019: {"touch_fly_down", "⇓"},
+
+	// PlayStation
+	0xE040: {"ps_cross", "✕"},
+	0xE041: {"ps_circle", "○"},
+	0xE042: {"ps_square", "□"},
+	0xE043: {"ps_triangle", "△"},
+	0xE044: {"ps_l1", "L1"},
+	0xE045: {"ps_r1", "R1"},
+	0xE046: {"ps_l2", "L2"},
+	0xE047: {"ps_r2", "R2"},
+	0xE048: {"ps_touchpad", "▭"},
+	0xE049: {"ps_options", "☰"},
+	0xE04A: {"ps_l3", "L3"},
+	0xE04B: {"ps_r3", "R3"},
+	0xE04C: {"ps_dpad_up", "↑"},
+	0xE04D: {"ps_dpad_left", "←"},
+	0xE04E: {"ps_dpad_down", "↓"},
+	0xE04F: {"ps_dpad_right", "→"},
+
+	// Nintendo Switch
+	0xE050: {"switch_a", "Ⓐ"},
+	0xE051: {"switch_b", "Ⓑ"},
+	0xE052: {"switch_x", "Ⓧ"},
+	0xE053: {"switch_y", "Ⓨ"},
+	0xE054: {"switch_l", "L"},
+	0xE055: {"switch_r", "R"},
+	0xE056: {"switch_zl", "ZL"},
+	0xE057: {"switch_zr", "ZR"},
+	0xE058: {"switch_minus", "⊖"},
+	0xE059: {"switch_plus", "⊕"},
+	0xE05A: {"switch_ls", "Ⓛ"},
+	0xE05B: {"switch_rs", "Ⓡ"},
+	0xE05C: {"switch_dpad_up", "↑"},
+	0xE05D: {"switch_dpad_left", "←"},
+	0xE05E: {"switch_dpad_down", "↓"},
+	0xE05F: {"switch_dpad_right", "→"},
+
+	// Mouse
+	0xE060: {"mouse_left", "🖱L"},
+	0xE061: {"mouse_right", "🖱R"},
+	0xE062: {"mouse_middle", "🖱M"},
+	0xE063: {"mouse", "🖱"},
+
+	// HUD and store icons
+	0xE100: {"food", "🍗"},
+	0xE101: {"armor", "🛡"},
+	0xE102: {"minecoin", "🪙"},
+	0xE103: {"token", "🎟"},
+	0xE104: {"agent", "🤖"},
+	0xE105: {"immersive_reader", "📖"},
+	0xE106: {"craftable_on", "✔"},
+	0xE107: {"craftable_off", "✘"},
+	0xE10C: {"heart", "♥"},
+}
+
+// IsGlyph reports whether r is in the private use range Bedrock Edition renders as glyphs.
+func IsGlyph(r rune) bool {
+	return r >= glyphFirst && r <= glyphLast
+}
+
+// ReplaceGlyphs replaces Bedrock Edition private use glyphs in s,
+// which render as boxes outside of the game.
+//
+// Known glyphs are replaced with a similar Unicode character,
+// or with their name in brackets if text is true, such as "[minecoin]".
+// Unknown glyphs are replaced with "[glyph]".
+func ReplaceGlyphs(s string, text bool) string {
+	if !strings.ContainsFunc(s, IsGlyph) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if !IsGlyph(r) {
+			b.WriteRune(r)
+			continue
+		}
+		g, ok := glyphs[r]
+		switch {
+		case !ok:
+			b.WriteString("[glyph]")
+		case text:
+			b.WriteString("[" + g.name + "]")
+		default:
+			b.WriteString(g.fallback)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package mcpe
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"time"
+)
+
+// LanServer is a server that answered a broadcast unconnected ping.
+type LanServer struct {
+	Addr   *net.UDPAddr
+	Status StatusResponse
+}
+
+// DiscoverLan broadcasts an unconnected ping to port on every local IPv4 subnet,
+// and collects the pongs received within duration d.
+//
+// This is how the in-game friends tab finds LAN games.
+func DiscoverLan(port uint16, d time.Duration) (servers []LanServer, err error) {
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	t := start.UnixMilli()
+	var buf bytes.Buffer
+	err = writeUnconnectedPing(&buf, t)
+	if err != nil {
+		return
+	}
+	sent := false
+	for _, ip := range broadcastAddrs() {
+		_, err = conn.WriteToUDP(buf.Bytes(), &net.UDPAddr{IP: ip, Port: int(port)})
+		sent = sent || err == nil
+	}
+	if !sent {
+		return
+	}
+
+	err = conn.SetReadDeadline(start.Add(d))
+	if err != nil {
+		return
+	}
+
+	seen := make(map[string]bool)
+	b := make([]byte, maxDatagramSize)
+	for {
+		var n int
+		var addr *net.UDPAddr
+		n, addr, err = conn.ReadFromUDP(b)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return servers, nil
+		}
+		if err != nil {
+			return
+		}
+		if seen[addr.String()] {
+			continue
+		}
+
+		_, status, err := readUnconnectedPong(bytes.NewReader(b[:n]))
+		if err != nil {
+			continue
+		}
+		status.Latency = time.Since(start)
+		seen[addr.String()] = true
+		servers = append(servers, LanServer{addr, status})
+	}
+}
+
+// broadcastAddrs returns the limited broadcast address
+// followed by the directed broadcast address of each IPv4 subnet the host is on.
+func broadcastAddrs() []net.IP {
+	ips := []net.IP{net.IPv4bcast}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ips
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() {
+			continue
+		}
+		ip := ipNet.IP.To4()
+		if ip == nil {
+			continue
+		}
+		mask := ipNet.Mask[len(ipNet.Mask)-net.IPv4len:]
+		bcast := make(net.IP, net.IPv4len)
+		for i := range ip {
+			bcast[i] = ip[i] | ^mask[i]
+		}
+		ips = append(ips, bcast)
+	}
+	return ips
+}
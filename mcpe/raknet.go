@@ -0,0 +1,348 @@
+package mcpe
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"time"
+
+	"bhv.sh/minefetch/internal/netctx"
+	"bhv.sh/minefetch/internal/stage"
+	"bhv.sh/minefetch/internal/wire"
+)
+
+// RaknetProtocol is the RakNet protocol version used by current Bedrock Edition clients.
+const RaknetProtocol byte = 11
+
+// MTU sizes tried during MTU discovery, from largest to smallest.
+var mtus = [...]uint16{1492, 1200, 576}
+
+// ProbeResponse contains the results of a RakNet connection handshake.
+//
+// Protocol is the RakNet protocol version the server accepted.
+// If the server replied with Incompatible Protocol Version, it is the version the server asked for.
+//
+// MTU is the maximum transmission unit negotiated through MTU discovery.
+//
+// Latency is the round-trip time of the Open Connection Request 1 that was answered.
+//
+// Ports reports whether each probed port answered Open Connection Request 1.
+type ProbeResponse struct {
+	GUID       int64
+	Security   bool
+	Encryption bool
+	MTU        uint16
+	Protocol   byte
+	Ports      map[uint16]bool
+	Latency    time.Duration
+}
+
+// Probe performs the [RakNet connection handshake] with the server at address,
+// stopping short of the game login.
+//
+// Unlike Status, this tells whether the server accepts connections, not just pings.
+// Once the handshake is done, a disconnection notification is sent.
+//
+// Open Connection Request 1 is also sent to each of ports on the same host,
+// such as the ports advertised by the server in its pong.
+//
+// Connections are made with d, and each request is given timeout to be answered.
+//
+// [RakNet connection handshake]: https://minecraft.wiki/w/RakNet#Open_Connection_Request_1
+func Probe(ctx context.Context, d Dialer, address string, ports []uint16, timeout time.Duration) (probe ProbeResponse, err error) {
+	conn, err := dial(ctx, d, address)
+	if err != nil {
+		err = stage.Wrap(stage.Connect, err)
+		return
+	}
+	defer conn.Close()
+	addr, err := netip.ParseAddrPort(conn.RemoteAddr().String())
+	if err != nil {
+		err = stage.Wrap(stage.Connect, err)
+		return
+	}
+	addr = netip.AddrPortFrom(addr.Addr().Unmap(), addr.Port())
+
+	var start time.Time
+	probe.Protocol = RaknetProtocol
+	var reply1 openConnectionReply1
+	for i := 0; i < len(mtus); i++ {
+		start = time.Now()
+		reply1, err = openConnection1(conn, probe.Protocol, mtus[i], netctx.Deadline(ctx, timeout))
+		var protoErr *incompatibleProtocolError
+		if errors.As(err, &protoErr) && protoErr.protocol != probe.Protocol {
+			probe.Protocol = protoErr.protocol
+			i--
+			continue
+		}
+		if !errors.Is(err, os.ErrDeadlineExceeded) {
+			break
+		}
+	}
+	if err != nil {
+		err = stage.Wrap(stage.OpenConnection1, err)
+		return
+	}
+	probe.Latency = time.Since(start)
+	probe.GUID = reply1.guid
+	probe.Security = reply1.security
+
+	conn.SetDeadline(netctx.Deadline(ctx, timeout))
+	err = writeOpenConnectionRequest2(conn, reply1, addr)
+	if err != nil {
+		err = stage.Wrap(stage.OpenConnection2, err)
+		return
+	}
+	reply2, err := readOpenConnectionReply2(conn)
+	if err != nil {
+		err = stage.Wrap(stage.OpenConnection2, err)
+		return
+	}
+	probe.MTU = reply2.mtu
+	probe.Encryption = reply2.encryption
+
+	// A disconnection notification is best-effort; the server times out the connection otherwise
+	writeDisconnectionNotification(conn)
+
+	probe.Ports = make(map[uint16]bool, len(ports))
+	for _, port := range ports {
+		probe.Ports[port] = acceptsConnections(ctx, d, netip.AddrPortFrom(addr.Addr(), port), probe.Protocol, timeout)
+	}
+
+	return
+}
+
+// acceptsConnections reports whether a RakNet server at addr answers Open Connection Request 1.
+func acceptsConnections(ctx context.Context, d Dialer, addr netip.AddrPort, protocol byte, timeout time.Duration) bool {
+	conn, err := dial(ctx, d, addr.String())
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	_, err = openConnection1(conn, protocol, mtus[len(mtus)-1], netctx.Deadline(ctx, timeout))
+	var protoErr *incompatibleProtocolError
+	return err == nil || errors.As(err, &protoErr)
+}
+
+type openConnectionReply1 struct {
+	guid     int64
+	security bool
+	cookie   uint32
+	mtu      uint16
+}
+
+type openConnectionReply2 struct {
+	guid       int64
+	mtu        uint16
+	encryption bool
+}
+
+type incompatibleProtocolError struct {
+	protocol byte
+}
+
+func (e *incompatibleProtocolError) Error() string {
+	return fmt.Sprint("incompatible protocol version, server uses: ", e.protocol)
+}
+
+func openConnection1(conn net.Conn, protocol byte, mtu uint16, deadline time.Time) (reply openConnectionReply1, err error) {
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return
+	}
+	err = writeOpenConnectionRequest1(conn, protocol, mtu)
+	if err != nil {
+		return
+	}
+	return readOpenConnectionReply1(conn)
+}
+
+// https://minecraft.wiki/w/RakNet#Open_Connection_Request_1
+func writeOpenConnectionRequest1(w io.Writer, protocol byte, mtu uint16) error {
+	buf := &bytes.Buffer{}
+	err1 := buf.WriteByte(wire.RakNetPacketIdOpenConnectionRequest1)
+	err2 := binary.Write(buf, binary.BigEndian, wire.RakNetMagic)
+	err3 := buf.WriteByte(protocol)
+	// The datagram is padded to the MTU, minus the IP and UDP headers
+	_, err4 := buf.Write(make([]byte, int(mtu)-28-buf.Len()))
+	_, err5 := w.Write(buf.Bytes())
+	return cmp.Or(err1, err2, err3, err4, err5)
+}
+
+// https://minecraft.wiki/w/RakNet#Open_Connection_Reply_1
+func readOpenConnectionReply1(r io.Reader) (reply openConnectionReply1, err error) {
+	br, err := readDatagram(r)
+	if err != nil {
+		return
+	}
+	id, err := br.ReadByte()
+	if err != nil {
+		return
+	}
+	switch id {
+	case wire.RakNetPacketIdOpenConnectionReply1:
+	case wire.RakNetPacketIdIncompatibleProtocol:
+		var protocol byte
+		protocol, err = br.ReadByte()
+		if err != nil {
+			return
+		}
+		err = &incompatibleProtocolError{protocol}
+		return
+	default:
+		err = &stage.PacketIdError{Id: int32(id)}
+		return
+	}
+
+	var magic [len(wire.RakNetMagic)]byte
+	err = binary.Read(br, binary.BigEndian, &magic)
+	if err != nil {
+		return
+	}
+	err1 := binary.Read(br, binary.BigEndian, &reply.guid)
+	err2 := binary.Read(br, binary.BigEndian, &reply.security)
+	if err = cmp.Or(err1, err2); err != nil {
+		return
+	}
+	if reply.security {
+		err = binary.Read(br, binary.BigEndian, &reply.cookie)
+		if err != nil {
+			return
+		}
+	}
+	err = binary.Read(br, binary.BigEndian, &reply.mtu)
+	return
+}
+
+// https://minecraft.wiki/w/RakNet#Open_Connection_Request_2
+func writeOpenConnectionRequest2(w io.Writer, reply openConnectionReply1, server netip.AddrPort) error {
+	buf := &bytes.Buffer{}
+	err1 := buf.WriteByte(wire.RakNetPacketIdOpenConnectionRequest2)
+	err2 := binary.Write(buf, binary.BigEndian, wire.RakNetMagic)
+	var err3 error
+	if reply.security {
+		// The client does not support the security challenge
+		err3 = cmp.Or(binary.Write(buf, binary.BigEndian, reply.cookie), buf.WriteByte(0))
+	}
+	err4 := writeAddress(buf, server)
+	err5 := binary.Write(buf, binary.BigEndian, reply.mtu)
+	err6 := binary.Write(buf, binary.BigEndian, time.Now().UnixNano())
+	_, err7 := w.Write(buf.Bytes())
+	return cmp.Or(err1, err2, err3, err4, err5, err6, err7)
+}
+
+// https://minecraft.wiki/w/RakNet#Open_Connection_Reply_2
+func readOpenConnectionReply2(r io.Reader) (reply openConnectionReply2, err error) {
+	br, err := readDatagram(r)
+	if err != nil {
+		return
+	}
+	id, err := br.ReadByte()
+	if err != nil {
+		return
+	}
+	if id != wire.RakNetPacketIdOpenConnectionReply2 {
+		err = &stage.PacketIdError{Id: int32(id)}
+		return
+	}
+
+	var magic [len(wire.RakNetMagic)]byte
+	err = binary.Read(br, binary.BigEndian, &magic)
+	if err != nil {
+		return
+	}
+	err = binary.Read(br, binary.BigEndian, &reply.guid)
+	if err != nil {
+		return
+	}
+	_, err = readAddress(br)
+	if err != nil {
+		return
+	}
+	err1 := binary.Read(br, binary.BigEndian, &reply.mtu)
+	err2 := binary.Read(br, binary.BigEndian, &reply.encryption)
+	err = cmp.Or(err1, err2)
+	return
+}
+
+// writeDisconnectionNotification sends an unreliable frame set containing a disconnection notification.
+//
+// https://minecraft.wiki/w/RakNet#Frame_Set_Packet
+func writeDisconnectionNotification(w io.Writer) error {
+	buf := &bytes.Buffer{}
+	buf.WriteByte(wire.RakNetPacketIdFrameSet)
+	buf.Write([]byte{0, 0, 0}) // Sequence number (uint24le)
+	buf.WriteByte(0)           // Flags (unreliable)
+	binary.Write(buf, binary.BigEndian, uint16(8))
+	buf.WriteByte(wire.RakNetPacketIdDisconnectionNotification)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// https://minecraft.wiki/w/RakNet#Data_types
+func writeAddress(w io.Writer, addr netip.AddrPort) error {
+	buf := &bytes.Buffer{}
+	ip := addr.Addr().Unmap()
+	if ip.Is4() {
+		buf.WriteByte(4)
+		for _, b := range ip.As4() {
+			buf.WriteByte(^b)
+		}
+		binary.Write(buf, binary.BigEndian, addr.Port())
+	} else {
+		buf.WriteByte(6)
+		binary.Write(buf, binary.LittleEndian, uint16(23)) // AF_INET6 on Windows
+		binary.Write(buf, binary.BigEndian, addr.Port())
+		binary.Write(buf, binary.BigEndian, uint32(0)) // Flow info
+		b := ip.As16()
+		buf.Write(b[:])
+		binary.Write(buf, binary.BigEndian, uint32(0)) // Scope ID
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func readAddress(r io.Reader) (addr netip.AddrPort, err error) {
+	var version byte
+	err = binary.Read(r, binary.BigEndian, &version)
+	if err != nil {
+		return
+	}
+	var port uint16
+	switch version {
+	case 4:
+		var b [4]byte
+		err1 := binary.Read(r, binary.BigEndian, &b)
+		err2 := binary.Read(r, binary.BigEndian, &port)
+		if err = cmp.Or(err1, err2); err != nil {
+			return
+		}
+		for i := range b {
+			b[i] = ^b[i]
+		}
+		addr = netip.AddrPortFrom(netip.AddrFrom4(b), port)
+	case 6:
+		var v struct {
+			Family   uint16
+			Port     uint16
+			FlowInfo uint32
+			Addr     [16]byte
+			ScopeId  uint32
+		}
+		err = binary.Read(r, binary.BigEndian, &v)
+		if err != nil {
+			return
+		}
+		addr = netip.AddrPortFrom(netip.AddrFrom16(v.Addr), v.Port)
+	default:
+		err = fmt.Errorf("%w: unknown address version: %v", stage.ErrProtocol, version)
+	}
+	return
+}
@@ -0,0 +1,64 @@
+package mcpe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"testing"
+
+	"bhv.sh/minefetch/internal/wire"
+)
+
+func FuzzReadOpenConnectionReply1(f *testing.F) {
+	for _, security := range []bool{false, true} {
+		buf := &bytes.Buffer{}
+		buf.WriteByte(wire.RakNetPacketIdOpenConnectionReply1)
+		binary.Write(buf, binary.BigEndian, wire.RakNetMagic)
+		binary.Write(buf, binary.BigEndian, int64(1234))
+		binary.Write(buf, binary.BigEndian, security)
+		if security {
+			binary.Write(buf, binary.BigEndian, uint32(5678))
+		}
+		binary.Write(buf, binary.BigEndian, uint16(1492))
+		f.Add(buf.Bytes())
+	}
+	f.Add([]byte{wire.RakNetPacketIdIncompatibleProtocol, 10})
+	f.Fuzz(func(t *testing.T, b []byte) {
+		readOpenConnectionReply1(bytes.NewReader(b))
+	})
+}
+
+func FuzzReadOpenConnectionReply2(f *testing.F) {
+	for _, addr := range []string{"127.0.0.1:19132", "[::1]:19133"} {
+		buf := &bytes.Buffer{}
+		buf.WriteByte(wire.RakNetPacketIdOpenConnectionReply2)
+		binary.Write(buf, binary.BigEndian, wire.RakNetMagic)
+		binary.Write(buf, binary.BigEndian, int64(1234))
+		writeAddress(buf, netip.MustParseAddrPort(addr))
+		binary.Write(buf, binary.BigEndian, uint16(1492))
+		buf.WriteByte(0)
+		f.Add(buf.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		readOpenConnectionReply2(bytes.NewReader(b))
+	})
+}
+
+func FuzzReadAddress(f *testing.F) {
+	for _, addr := range []string{"127.0.0.1:19132", "[::1]:19133"} {
+		buf := &bytes.Buffer{}
+		writeAddress(buf, netip.MustParseAddrPort(addr))
+		f.Add(buf.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		addr, err := readAddress(bytes.NewReader(b))
+		if err != nil || !addr.Addr().Is4() {
+			return
+		}
+		buf := &bytes.Buffer{}
+		writeAddress(buf, addr)
+		if !bytes.HasPrefix(b, buf.Bytes()) {
+			t.Errorf("%v is written as %x, read from %x", addr, buf.Bytes(), b)
+		}
+	})
+}
@@ -0,0 +1,266 @@
+// Package mcpe implements a subset of the RakNet protocol as used by Bedrock Edition.
+package mcpe
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"bhv.sh/minefetch/internal/netctx"
+	"bhv.sh/minefetch/internal/stage"
+	"bhv.sh/minefetch/internal/wire"
+)
+
+// StatusResponse contains general server info provided by an unconnected pong.
+//
+// GUID is the server GUID from the pong header,
+// and ID is the one in the pong string. Both are normally the same.
+//
+// Latencies holds the round-trip time of each ping sent by StatusPing, with lost pings stored as 0.
+type StatusResponse struct {
+	Edition string
+	Name    string
+	Version struct {
+		Name     string
+		Protocol int
+	}
+	Players struct {
+		Max    int
+		Online int
+	}
+	GUID     int64
+	ID       string
+	Level    string
+	GameMode struct {
+		Name string
+		ID   int
+	}
+	Port struct {
+		IPv4, IPv6 uint16
+	}
+	Latency   time.Duration
+	Latencies []time.Duration
+	Raw       string
+}
+
+// Status attempts to get general server info using the [RakNet protocol].
+//
+// This is the same interface used by the in-game server list.
+//
+// The server at address is connected to with d, and the pong must arrive within timeout.
+// The ping is resent as configured by retry if no pong arrives,
+// and Latency is measured from the last ping sent.
+//
+// [RakNet protocol]: https://minecraft.wiki/w/RakNet
+func Status(ctx context.Context, d Dialer, address string, timeout time.Duration, retry Retry) (status StatusResponse, err error) {
+	return StatusPing(ctx, d, address, 1, 0, timeout, retry)
+}
+
+// StatusPing is like Status, but sends count unconnected pings interval apart from the same socket.
+//
+// A ping is lost if no matching pong is received within timeout.
+// A timeout of 0 waits indefinitely. Only the first ping is resent as configured by retry.
+//
+// Latency is the average round-trip time of all pings that were not lost.
+func StatusPing(ctx context.Context, d Dialer, address string, count int, interval, timeout time.Duration, retry Retry) (status StatusResponse, err error) {
+	start := time.Now()
+	conn, err := dial(ctx, d, address)
+	if err != nil {
+		err = stage.Wrap(stage.Connect, err)
+		return
+	}
+	defer conn.Close()
+	until := netctx.Deadline(ctx, timeout)
+	conn.SetDeadline(until)
+	t := start.UnixMilli()
+	first := t
+	// Retries resend the same ping, so that a late pong to an earlier one still matches
+	err = retry.Exchange(conn, until, func() error {
+		start = time.Now()
+		return writeUnconnectedPing(conn, t)
+	}, func() (err error) {
+		status, err = readMatchingPong(conn, first, t)
+		return
+	})
+	if err != nil {
+		err = stage.Wrap(stage.Pong, err)
+		return
+	}
+	status.Latency = time.Since(start)
+	if count <= 1 {
+		return
+	}
+
+	status.Latencies = make([]time.Duration, 0, count)
+	status.Latencies = append(status.Latencies, status.Latency)
+	for range count - 1 {
+		select {
+		case <-time.After(interval - time.Since(start)):
+		case <-ctx.Done():
+			return status, stage.Wrap(stage.Pong, ctx.Err())
+		}
+		start = time.Now()
+		conn.SetDeadline(netctx.Deadline(ctx, timeout))
+		t := start.UnixMilli()
+		err1 := writeUnconnectedPing(conn, t)
+		_, err2 := readMatchingPong(conn, first, t)
+		if cmp.Or(err1, err2) != nil {
+			status.Latencies = append(status.Latencies, 0)
+			continue
+		}
+		status.Latencies = append(status.Latencies, time.Since(start))
+	}
+
+	var sum time.Duration
+	var n int
+	for _, l := range status.Latencies {
+		if l != 0 {
+			sum += l
+			n++
+		}
+	}
+	status.Latency = sum / time.Duration(n)
+	return
+}
+
+// readMatchingPong reads pongs until one answers the ping sent at time t.
+//
+// Late pongs to pings sent since first, but before t, are discarded.
+// Pongs with any other time are accepted, as not all servers echo the ping time.
+func readMatchingPong(r io.Reader, first, t int64) (status StatusResponse, err error) {
+	for {
+		var pongTime int64
+		pongTime, status, err = readUnconnectedPong(r)
+		if err != nil || pongTime < first || pongTime >= t {
+			return
+		}
+	}
+}
+
+// https://minecraft.wiki/w/RakNet#Unconnected_Ping
+func writeUnconnectedPing(w io.Writer, t int64) error {
+	buf := &bytes.Buffer{}
+	err1 := buf.WriteByte(wire.RakNetPacketIdUnconnectedPing)
+	err2 := binary.Write(buf, binary.BigEndian, t)
+	err3 := binary.Write(buf, binary.BigEndian, wire.RakNetMagic)
+	err4 := binary.Write(buf, binary.BigEndian, int64(0))
+	_, err5 := w.Write(buf.Bytes())
+	return cmp.Or(err1, err2, err3, err4, err5)
+}
+
+// https://minecraft.wiki/w/RakNet#Unconnected_Pong
+func readUnconnectedPong(r io.Reader) (t int64, status StatusResponse, err error) {
+	br, err := readDatagram(r)
+	if err != nil {
+		return
+	}
+	id, err := br.ReadByte()
+	if err != nil {
+		return
+	}
+	if id != wire.RakNetPacketIdUnconnectedPong {
+		err = &stage.PacketIdError{Id: int32(id)}
+		return
+	}
+	err = binary.Read(br, binary.BigEndian, &t)
+	if err != nil {
+		return
+	}
+	var guid int64
+	err = binary.Read(br, binary.BigEndian, &guid)
+	if err != nil {
+		return
+	}
+	var magic [len(wire.RakNetMagic)]byte
+	err = binary.Read(br, binary.BigEndian, &magic)
+	if err != nil {
+		return
+	}
+
+	var n uint16
+	err = binary.Read(br, binary.BigEndian, &n)
+	if err != nil {
+		return
+	}
+
+	b := make([]byte, n)
+	_, err = io.ReadFull(br, b)
+	if err != nil {
+		return
+	}
+	status, err = ParseStatus(string(b))
+	status.GUID = guid
+	return
+}
+
+// ParseStatus parses the server info string of a pong, such as a saved Raw field.
+// GUID and the latencies are not set.
+func ParseStatus(s string) (status StatusResponse, err error) {
+	status.Raw = s
+	if len(s) == 0 {
+		err = fmt.Errorf("%w: zero-length response", stage.ErrProtocol)
+		return
+	}
+
+	// Fields after the player counts are optional
+	ss := strings.Split(strings.TrimSuffix(s, ";"), ";")
+	if len(ss) < 6 {
+		err = fmt.Errorf("%w: expected at least 6 fields, got: %v", stage.ErrProtocol, len(ss))
+		return
+	}
+	status.Edition = ss[0]
+	status.Name = ss[1]
+	status.Version.Protocol, err = strconv.Atoi(ss[2])
+	if err != nil {
+		return
+	}
+	status.Version.Name = ss[3]
+	status.Players.Online, err = strconv.Atoi(ss[4])
+	if err != nil {
+		return
+	}
+	status.Players.Max, err = strconv.Atoi(ss[5])
+	if err != nil {
+		return
+	}
+	if len(ss) > 6 {
+		status.ID = ss[6]
+	}
+	if len(ss) > 7 {
+		status.Level = ss[7]
+	}
+	if len(ss) > 8 {
+		status.GameMode.Name = ss[8]
+	}
+	if len(ss) <= 9 {
+		return
+	}
+	status.GameMode.ID, err = strconv.Atoi(ss[9])
+	if err != nil {
+		return
+	}
+	if len(ss) == 10 {
+		return
+	}
+	ipv4Port, err := strconv.ParseUint(ss[10], 10, 16)
+	if err != nil {
+		return
+	}
+	status.Port.IPv4 = uint16(ipv4Port)
+	if len(ss) == 11 {
+		return
+	}
+	ipv6Port, err := strconv.ParseUint(ss[11], 10, 16)
+	if err != nil {
+		return
+	}
+	status.Port.IPv6 = uint16(ipv6Port)
+
+	return
+}
@@ -0,0 +1,66 @@
+package mcpe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"bhv.sh/minefetch/internal/stage"
+	"bhv.sh/minefetch/internal/wire"
+)
+
+// datagrams is a connection that reads one datagram at a time.
+type datagrams [][]byte
+
+func (d *datagrams) Read(b []byte) (n int, err error) {
+	n = copy(b, (*d)[0])
+	*d = (*d)[1:]
+	return
+}
+
+func TestReadUnconnectedPongTruncated(t *testing.T) {
+	s := "MCPE;Dedicated Server;766;1.21.50;0;10;"
+	buf := &bytes.Buffer{}
+	buf.WriteByte(wire.RakNetPacketIdUnconnectedPong)
+	binary.Write(buf, binary.BigEndian, int64(1))
+	binary.Write(buf, binary.BigEndian, int64(1234))
+	binary.Write(buf, binary.BigEndian, wire.RakNetMagic)
+	// The string is declared longer than the datagram, and must not be completed with the next one
+	binary.Write(buf, binary.BigEndian, uint16(2*len(s)))
+	buf.WriteString(s)
+	conn := &datagrams{buf.Bytes(), []byte(s)}
+
+	_, _, err := readUnconnectedPong(conn)
+	if !errors.Is(err, stage.ErrProtocol) {
+		t.Errorf("err = %v, want %v", err, stage.ErrProtocol)
+	}
+	if len(*conn) != 1 {
+		t.Error("read the next datagram")
+	}
+}
+
+func FuzzReadUnconnectedPong(f *testing.F) {
+	s := "MCPE;Dedicated Server;766;1.21.50;0;10;1234;Bedrock level;Survival;1;19132;19133;"
+	buf := &bytes.Buffer{}
+	buf.WriteByte(wire.RakNetPacketIdUnconnectedPong)
+	binary.Write(buf, binary.BigEndian, int64(1))
+	binary.Write(buf, binary.BigEndian, int64(1234))
+	binary.Write(buf, binary.BigEndian, wire.RakNetMagic)
+	binary.Write(buf, binary.BigEndian, uint16(len(s)))
+	buf.WriteString(s)
+	f.Add(buf.Bytes())
+	f.Fuzz(func(t *testing.T, b []byte) {
+		readUnconnectedPong(bytes.NewReader(b))
+	})
+}
+
+func FuzzParseStatus(f *testing.F) {
+	f.Add("MCPE;Dedicated Server;766;1.21.50;0;10;1234;Bedrock level;Survival;1;19132;19133;")
+	f.Fuzz(func(t *testing.T, s string) {
+		status, err := ParseStatus(s)
+		if err == nil && status.Raw != s {
+			t.Errorf("Raw is %q, want %q", status.Raw, s)
+		}
+	})
+}
@@ -12,11 +12,13 @@ import (
 //
 // Bedrock Edition does not support the strikethrough and underlined codes.
 //
+// Control characters and escape sequences in s are removed, see term.Sanitize.
+//
 // [Minecraft legacy formatting]: https://minecraft.wiki/w/Formatting_codes
 func LegacyTextAnsi(s string) string {
 	var b strings.Builder
 	esc := false
-	for _, v := range s {
+	for _, v := range term.Sanitize(s) {
 		if !esc {
 			if v == '§' {
 				esc = true
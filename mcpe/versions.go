@@ -0,0 +1,19 @@
+package mcpe
+
+//go:generate go run gen_versions.go
+
+// VersionIdName maps Bedrock Edition [protocol version numbers] to release names.
+//
+// Only releases are listed; previews and betas share protocol numbers with releases.
+// For protocol numbers that map to multiple releases, only the earliest and latest are listed.
+// For example, the protocol number 766 maps to "1.21.50 – 1.21.51".
+//
+// [protocol version numbers]: https://minecraft.wiki/w/Protocol_version#Bedrock_Edition_2
+var VersionIdName map[int]string = versionIdName
+
+// VersionNameId maps release names to Bedrock Edition [protocol version numbers].
+//
+// Multiple names may map to the same protocol number.
+//
+// [protocol version numbers]: https://minecraft.wiki/w/Protocol_version#Bedrock_Edition_2
+var VersionNameId map[string]int = versionNameId
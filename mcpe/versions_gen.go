@@ -0,0 +1,167 @@
+// Code generated by "go run gen_versions.go". DO NOT EDIT.
+
+package mcpe
+
+var versionIdName = map[int]string{
+	137: "1.2.0",
+	261: "1.4.0",
+	274: "1.5.0",
+	282: "1.6.0",
+	291: "1.7.0",
+	313: "1.8.0",
+	332: "1.9.0",
+	340: "1.10.0",
+	354: "1.11.0",
+	361: "1.12.0",
+	388: "1.13.0",
+	389: "1.14.0 – 1.14.30",
+	390: "1.14.60",
+	407: "1.16.0 – 1.16.1",
+	408: "1.16.20 – 1.16.40",
+	419: "1.16.100",
+	422: "1.16.200 – 1.16.201",
+	428: "1.16.210",
+	431: "1.16.220 – 1.16.221",
+	440: "1.17.0 – 1.17.2",
+	448: "1.17.10 – 1.17.11",
+	465: "1.17.30 – 1.17.34",
+	471: "1.17.40 – 1.17.41",
+	475: "1.18.0 – 1.18.2",
+	486: "1.18.10 – 1.18.12",
+	503: "1.18.30 – 1.18.33",
+	527: "1.19.0 – 1.19.2",
+	534: "1.19.10 – 1.19.11",
+	544: "1.19.20",
+	545: "1.19.21 – 1.19.22",
+	554: "1.19.30 – 1.19.31",
+	557: "1.19.40 – 1.19.41",
+	560: "1.19.50 – 1.19.51",
+	567: "1.19.60 – 1.19.62",
+	568: "1.19.63",
+	575: "1.19.70 – 1.19.73",
+	582: "1.19.80 – 1.19.83",
+	589: "1.20.0 – 1.20.1",
+	594: "1.20.10 – 1.20.15",
+	618: "1.20.30 – 1.20.32",
+	622: "1.20.40 – 1.20.41",
+	630: "1.20.50 – 1.20.51",
+	649: "1.20.60 – 1.20.62",
+	662: "1.20.70 – 1.20.73",
+	671: "1.20.80 – 1.20.81",
+	685: "1.21.0 – 1.21.1",
+	686: "1.21.2 – 1.21.3",
+	712: "1.21.20 – 1.21.23",
+	729: "1.21.30 – 1.21.31",
+	748: "1.21.40 – 1.21.44",
+	766: "1.21.50 – 1.21.51",
+	776: "1.21.60 – 1.21.62",
+	786: "1.21.70 – 1.21.73",
+	800: "1.21.80 – 1.21.84",
+	818: "1.21.90 – 1.21.92",
+	819: "1.21.93 – 1.21.94",
+	827: "1.21.100 – 1.21.101",
+	844: "1.21.110 – 1.21.111",
+}
+
+var versionNameId = map[string]int{
+	"1.2.0":    137,
+	"1.4.0":    261,
+	"1.5.0":    274,
+	"1.6.0":    282,
+	"1.7.0":    291,
+	"1.8.0":    313,
+	"1.9.0":    332,
+	"1.10.0":   340,
+	"1.11.0":   354,
+	"1.12.0":   361,
+	"1.13.0":   388,
+	"1.14.0":   389,
+	"1.14.30":  389,
+	"1.14.60":  390,
+	"1.16.0":   407,
+	"1.16.1":   407,
+	"1.16.20":  408,
+	"1.16.40":  408,
+	"1.16.100": 419,
+	"1.16.200": 422,
+	"1.16.201": 422,
+	"1.16.210": 428,
+	"1.16.220": 431,
+	"1.16.221": 431,
+	"1.17.0":   440,
+	"1.17.2":   440,
+	"1.17.10":  448,
+	"1.17.11":  448,
+	"1.17.30":  465,
+	"1.17.34":  465,
+	"1.17.40":  471,
+	"1.17.41":  471,
+	"1.18.0":   475,
+	"1.18.2":   475,
+	"1.18.10":  486,
+	"1.18.12":  486,
+	"1.18.30":  503,
+	"1.18.33":  503,
+	"1.19.0":   527,
+	"1.19.2":   527,
+	"1.19.10":  534,
+	"1.19.11":  534,
+	"1.19.20":  544,
+	"1.19.21":  545,
+	"1.19.22":  545,
+	"1.19.30":  554,
+	"1.19.31":  554,
+	"1.19.40":  557,
+	"1.19.41":  557,
+	"1.19.50":  560,
+	"1.19.51":  560,
+	"1.19.60":  567,
+	"1.19.62":  567,
+	"1.19.63":  568,
+	"1.19.70":  575,
+	"1.19.73":  575,
+	"1.19.80":  582,
+	"1.19.83":  582,
+	"1.20.0":   589,
+	"1.20.1":   589,
+	"1.20.10":  594,
+	"1.20.15":  594,
+	"1.20.30":  618,
+	"1.20.32":  618,
+	"1.20.40":  622,
+	"1.20.41":  622,
+	"1.20.50":  630,
+	"1.20.51":  630,
+	"1.20.60":  649,
+	"1.20.62":  649,
+	"1.20.70":  662,
+	"1.20.73":  662,
+	"1.20.80":  671,
+	"1.20.81":  671,
+	"1.21.0":   685,
+	"1.21.1":   685,
+	"1.21.2":   686,
+	"1.21.3":   686,
+	"1.21.20":  712,
+	"1.21.23":  712,
+	"1.21.30":  729,
+	"1.21.31":  729,
+	"1.21.40":  748,
+	"1.21.44":  748,
+	"1.21.50":  766,
+	"1.21.51":  766,
+	"1.21.60":  776,
+	"1.21.62":  776,
+	"1.21.70":  786,
+	"1.21.73":  786,
+	"1.21.80":  800,
+	"1.21.84":  800,
+	"1.21.90":  818,
+	"1.21.92":  818,
+	"1.21.93":  819,
+	"1.21.94":  819,
+	"1.21.100": 827,
+	"1.21.101": 827,
+	"1.21.110": 844,
+	"1.21.111": 844,
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"bhv.sh/minefetch/mc"
+	"bhv.sh/minefetch/mcpe"
+)
+
+// offline reports whether a saved response is rendered instead of fetching it.
+func offline() bool {
+	return cfg.from.file != "" || cfg.from.stdin
+}
+
+// loadResults reads the response given by --from-file or --from-stdin,
+// and returns it as the result of the matching probe, with all probes finished.
+// Only the matching probe is enabled.
+//
+// The response may be a status JSON, a Bedrock pong string or a query stat response,
+// as printed by --output raw.
+func loadResults() (results *results, err error) {
+	var b []byte
+	if cfg.from.stdin {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(cfg.from.file)
+	}
+	if err != nil {
+		return
+	}
+	s := string(b)
+
+	cfg.status, cfg.bedrock.enabled, cfg.query.enabled = false, false, false
+	cfg.crossplay, cfg.raknet, cfg.blocked, cfg.cracked, cfg.rcon.enabled = false, false, false, false, false
+
+	results = newResults()
+	switch {
+	// Query keys and values are NUL-terminated
+	case strings.ContainsRune(s, 0):
+		var query mc.QueryResponse
+		query, err = mc.ParseQuery(strings.TrimSuffix(s, "\n"))
+		results.set(queryProbe.name, result[mc.QueryResponse]{v: query, success: true})
+		cfg.query.enabled = true
+	case strings.HasPrefix(strings.TrimSpace(s), "{"):
+		var status mc.StatusResponse
+		status, err = mc.ParseStatus(strings.TrimSpace(s))
+		results.set(statusProbe.name, result[mc.StatusResponse]{v: status, success: true})
+		cfg.status = true
+	default:
+		var status mcpe.StatusResponse
+		status, err = mcpe.ParseStatus(strings.TrimSpace(s))
+		results.set(bedrockProbe.name, result[mcpe.StatusResponse]{v: status, success: true})
+		cfg.bedrock.enabled = true
+	}
+	if err != nil {
+		return
+	}
+
+	for _, done := range results.done {
+		close(done)
+	}
+	close(results.finished)
+	return
+}
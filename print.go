@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"net"
 	"strconv"
@@ -10,85 +11,130 @@ import (
 	"unicode/utf8"
 
 	"bhv.sh/minefetch/internal/image/pngconfig"
-	"bhv.sh/minefetch/internal/mc"
-	"bhv.sh/minefetch/internal/mcpe"
 	"bhv.sh/minefetch/internal/term"
+	"bhv.sh/minefetch/mc"
+	"bhv.sh/minefetch/mcpe"
 )
 
-const padding = 2
-
-var lines = 0
+func addErr(s *section, label string, err error) {
+	s.addSeverity(severityWarn, label, term.DarkYellow+"Failed "+formatErr(label, err))
+}
 
-func printLine(label string, data any) {
-	ss := strings.Split(fmt.Sprint(data), "\n")
-	if cfg.icon.enabled {
-		fmt.Print(term.Fwd(cfg.icon.size + padding))
-	}
-	if len(ss) == 1 && term.RemoveCsi(ss[0]) == "" {
-		ss[0] = term.Gray + "(empty)"
+func formatErr(label string, err error) string {
+	msg, hint := explainErr(label, err)
+	s := term.Gray + "(" + msg + term.Gray + ")"
+	if hint != "" {
+		s += "\n" + term.Gray + hint
 	}
-	fmt.Println(term.Bold + term.Blue + label + term.Reset + ": " + ss[0] + term.Reset)
-	n := uint(len(ss))
-	if cfg.maxList != 0 && n > cfg.maxList {
-		n = cfg.maxList
-		ss[n-1] = fmt.Sprintf(term.Gray+"(%v more)", len(ss)-int(cfg.maxList))
-	}
-	for _, v := range ss[1:n] {
-		fwd := uint(len(label)) + 2
-		if cfg.icon.enabled && term.ColorSupport != term.NoColorSupport {
-			fwd += cfg.icon.size + padding
-			fmt.Println(term.Fwd(fwd) + v + term.Reset)
-		} else {
-			fmt.Println(strings.Repeat(" ", int(fwd)) + v + term.Reset)
+	return s
+}
+
+// explainErr describes err in plain language, with a hint on what to try next if there is one.
+func explainErr(label string, err error) (msg, hint string) {
+	var e *mc.Error
+	if !errors.As(err, &e) {
+		return term.Sanitize(err.Error()), ""
+	}
+	bedrock := label == "Bedrock"
+	msg = e.Stage + ": "
+	switch e.Kind {
+	case mc.KindNotFound:
+		msg += "domain does not exist"
+		hint = "check the address for typos"
+	case mc.KindRefused:
+		msg += "port closed"
+		switch label {
+		case "Bedrock":
+			hint = "is this a Java server? try without --bedrock"
+		case "Query":
+			hint = "is query enabled? try --query-port"
+		case "RCON":
+			hint = "is RCON enabled? try --rcon-port"
+		default:
+			hint = "is this a Bedrock server? try --bedrock"
+		}
+	case mc.KindReset:
+		msg += "connection closed by server"
+		if e.Stage == mc.StepStatusResponse {
+			hint = "some servers only respond to a second request; try again"
 		}
+	case mc.KindTimeout:
+		msg += "no response"
+		hint = "the server may be offline or behind a firewall; try --timeout"
+	case mc.KindProtocol:
+		msg += "invalid response"
+		if !bedrock && e.Stage != mc.StepQueryHandshake && e.Stage != mc.StepQueryStatus {
+			hint = "server answered with Bedrock protocol on TCP? try --bedrock"
+		}
+	case mc.KindPacketId:
+		msg += "unexpected packet"
+		if !bedrock {
+			hint = "the server may not support this protocol version; try --proto"
+		}
+	case mc.KindJson:
+		msg += "malformed status JSON"
+		hint = "see the response with --output raw"
+	default:
+		msg = term.Sanitize(err.Error())
 	}
-	lines += len(ss)
+	return
 }
 
-func printErr(label string, err error) {
-	printLine(label, term.DarkYellow+"Failed "+term.Gray+"("+err.Error()+term.Gray+")")
+func addTimeout(s *section, label string) {
+	s.addSeverity(severityWarn, label, term.DarkYellow+"Timed out")
 }
 
-func printTimeout(label string) {
-	printLine(label, term.DarkYellow+"Timed out")
-}
+var ipCache = map[string]string{}
 
-func printNetInfo(host string, port uint16) {
-	var ip string
-	if net.ParseIP(host) == nil {
+// lookupIP returns the first IP address of host, or an empty string if the lookup fails.
+// Lookups are cached, as the report is rebuilt each time a probe finishes.
+func lookupIP(host string) string {
+	ip, ok := ipCache[host]
+	if !ok {
 		ips, err := net.LookupIP(host)
 		if err == nil {
 			ip = ips[0].String()
 		}
+		ipCache[host] = ip
+	}
+	return ip
+}
+
+// netFields adds the host, IP and port fields.
+// bedrock and crossplay report whether the Bedrock server and crossplay fields were shown.
+func netFields(s *section, host string, port uint16, bedrock, crossplay bool) {
+	var ip string
+	if net.ParseIP(host) == nil {
+		ip = lookupIP(host)
 	} else {
 		ip = host
 		host = ""
 	}
 	if host != "" {
-		printLine("Host", cfg.host)
+		s.add("Host", cfg.host)
 		if host != cfg.host {
-			printLine("SRV", host)
+			s.add("SRV", host)
 		}
 	}
 	if ip != "" {
-		printLine("IP", ip)
+		s.add("IP", ip)
 	}
 	if port == 0 {
 		port = cfg.port
 	}
-	if cfg.bedrock.enabled {
+	if bedrock {
 		port = cfg.bedrock.port
 	}
 	if port != 0 {
-		printLine("Port", port)
+		s.add("Port", port)
 	}
-	if cfg.crossplay {
-		printLine("Bedrock port", cfg.bedrock.port)
+	if crossplay {
+		s.add("Bedrock port", cfg.bedrock.port)
 	}
 }
 
-func printMotd(s string) {
-	ss := strings.Split(s, "\n")
+func motdField(s *section, motd string) {
+	ss := strings.Split(motd, "\n")
 	for i, s := range ss {
 		ss[i] = term.TrimSpace(s)
 	}
@@ -101,59 +147,87 @@ func printMotd(s string) {
 		j := (i + 1) % 2
 		ss[i] = strings.Repeat(" ", (n[j]-n[i])/2) + ss[i]
 	}
-	printLine("MOTD", strings.Join(ss, "\n"))
+	s.add("MOTD", strings.Join(ss, "\n"))
+}
+
+func latencyField(s *section, latency time.Duration, latencies []time.Duration) {
+	// Saved responses have no latency
+	if offline() {
+		return
+	}
+	if len(latencies) <= 1 {
+		s.add("Ping", fmt.Sprint(latencyColor(latency), latency.Milliseconds(), " ms"))
+		return
+	}
+	stats := newLatencyStats(latencies)
+	if stats.received == 0 {
+		s.addSeverity(severityBad, "Ping", fmt.Sprintf(term.Red+"%v sent, 0 received, 100%% loss", stats.sent))
+		return
+	}
+	v := fmt.Sprint(latencyColor(stats.avg), stats.avg.Milliseconds(), " ms")
+	v += fmt.Sprintf("\n"+term.Reset+"min/avg/max/mdev = %v/%v/%v/%v ms",
+		formatLatency(stats.min), formatLatency(stats.avg), formatLatency(stats.max), formatLatency(stats.mdev))
+	v += fmt.Sprintf("\n"+term.Reset+"jitter %v ms", formatLatency(stats.jitter))
+	loss := latencyColor(0)
+	if stats.received != stats.sent {
+		loss = term.Red
+	}
+	v += fmt.Sprintf("\n"+term.Reset+"%v sent, %v received, %v%.0f%% loss", stats.sent, stats.received, loss, stats.loss())
+	s.add("Ping", v)
 }
 
-func printLatency(latency time.Duration) {
+func latencyColor(latency time.Duration) string {
 	ms := latency.Milliseconds()
-	var c string
 	switch {
 	case ms < 50:
-		c = term.Green
+		return term.Green
 	case ms < 100:
-		c = term.Yellow
+		return term.Yellow
 	default:
-		c = term.Red
+		return term.Red
 	}
-	printLine("Ping", fmt.Sprint(c, ms, " ms"))
 }
 
-func printPlayers(online, max int, sample []string) {
-	s := fmt.Sprintf("%v"+term.Gray+"/"+term.Reset+"%v", online, max)
-	for _, v := range sample {
-		s += "\n" + mc.LegacyTextAnsi(v)
-	}
-	printLine("Players", s)
+func formatLatency(latency time.Duration) string {
+	return latencyColor(latency) + fmt.Sprintf("%.1f", float64(latency.Microseconds())/1000) + term.Reset
 }
 
-func printStatus(status *mc.StatusResponse) {
-	if cfg.icon.enabled {
-		printIcon(status.Icon)
+func playersField(s *section, online, max int, sample []string) {
+	v := fmt.Sprintf("%v"+term.Gray+"/"+term.Reset+"%v", online, max)
+	for _, name := range sample {
+		v += "\n" + mc.LegacyTextAnsi(name)
 	}
+	s.add("Players", v)
+}
 
-	printMotd(status.Motd.Ansi())
+func statusFields(s *section, status *mc.StatusResponse) {
+	motdField(s, status.Motd.Ansi())
 
-	printLatency(status.Latency)
+	if status.PingErr != nil {
+		addErr(s, "Ping", status.PingErr)
+	} else {
+		latencyField(s, status.Latency, status.Latencies)
+	}
 
-	printLine("Version", mc.LegacyTextAnsi(status.Version.Name))
+	s.add("Version", mc.LegacyTextAnsi(status.Version.Name))
 
 	{
 		var sample []string
 		for _, p := range status.Players.Sample {
 			sample = append(sample, p.Name)
 		}
-		printPlayers(status.Players.Online, status.Players.Max, sample)
+		playersField(s, status.Players.Online, status.Players.Max, sample)
 	}
 
 	{
-		var s string
+		var v string
 		protoVerName, ok := mc.VersionIdName[status.Version.Protocol]
 		if ok {
-			s = fmt.Sprintf("%v "+term.Gray+"(%v)", protoVerName, status.Version.Protocol)
+			v = fmt.Sprintf("%v "+term.Gray+"(%v)", protoVerName, status.Version.Protocol)
 		} else {
-			s = strconv.Itoa(int(status.Version.Protocol))
+			v = strconv.Itoa(int(status.Version.Protocol))
 		}
-		printLine("Protocol", s)
+		s.add("Protocol", v)
 	}
 
 	if status.Icon != nil {
@@ -162,190 +236,283 @@ func printStatus(status *mc.StatusResponse) {
 		if iconConfig.Interlaced {
 			interlaced = "Interlaced "
 		}
-		printLine("Icon", fmt.Sprintf("%v%v-bit %v", interlaced, iconConfig.BitDepth, formatColorType(iconConfig.ColorType)))
+		s.add("Icon", fmt.Sprintf("%v%v-bit %v", interlaced, iconConfig.BitDepth, formatColorType(iconConfig.ColorType)))
 	} else {
-		printLine("Icon", "Default")
+		s.add("Icon", "Default")
 	}
 
-	printLine("Secure chat", formatBool(!status.EnforcesSecureChat, "Not enforced", "Enforced"))
+	s.add("Secure chat", formatBool(!status.EnforcesSecureChat, "Not enforced", "Enforced"))
 
 	if status.PreventsChatReports {
-		printLine("Prevents chat reports", term.Green+"Yes")
+		s.addSeverity(severityGood, "Prevents chat reports", term.Green+"Yes")
 	}
 
 	if len(status.Forge.Mods) > 0 {
 		mods := make([]string, 0, len(status.Forge.Mods))
 		for _, m := range status.Forge.Mods {
-			mods = append(mods, m.Name+" "+term.Gray+m.Version)
+			mods = append(mods, term.Sanitize(m.Name)+" "+term.Gray+term.Sanitize(m.Version))
+		}
+		s.add("Mods", strings.Join(mods, "\n"))
+	}
+}
+
+func bedrockFields(s *section, status mcpe.StatusResponse, probe *mcpe.ProbeResponse, query *mc.QueryResponse) {
+	noColor := term.ColorSupport == term.NoColorSupport
+	s.add("Name", mcpe.LegacyTextAnsi(mcpe.ReplaceGlyphs(status.Name, noColor)))
+	s.add("Level", mcpe.LegacyTextAnsi(mcpe.ReplaceGlyphs(status.Level, noColor)))
+	latencyField(s, status.Latency, status.Latencies)
+	s.add("Version", mcpe.LegacyTextAnsi(status.Version.Name))
+	if query != nil {
+		// The pong has no player list
+		playersField(s, status.Players.Online, status.Players.Max, query.Players.Sample)
+	} else {
+		playersField(s, status.Players.Online, status.Players.Max, nil)
+	}
+
+	{
+		var v string
+		sev := severityNone
+		protoVerName, ok := mcpe.VersionIdName[status.Version.Protocol]
+		if ok {
+			v = fmt.Sprintf("%v "+term.Gray+"(%v)", protoVerName, status.Version.Protocol)
+		} else {
+			v = strconv.Itoa(status.Version.Protocol)
+		}
+		// Proxies often forward the backend version but advertise their own protocol
+		if proto, ok := mcpe.VersionNameId[status.Version.Name]; ok && proto != status.Version.Protocol {
+			v += fmt.Sprintf("\n"+term.DarkYellow+"Version %v is protocol %v; is this a proxy?", term.Sanitize(status.Version.Name), proto)
+			sev = severityWarn
+		}
+		s.addSeverity(sev, "Protocol", v)
+	}
+	if query != nil && query.Software != "" {
+		s.add("Software", term.Sanitize(query.Software)+" "+term.Gray+"(reported by query)")
+	} else if sw, ok := mcpe.Fingerprint(status, probe); ok {
+		color := term.Gray
+		switch sw.Confidence {
+		case mcpe.High:
+			color = term.Green
+		case mcpe.Medium:
+			color = term.Yellow
 		}
-		printLine("Mods", strings.Join(mods, "\n"))
+		s.add("Software", fmt.Sprintf("%v %v(%v confidence: %v)", sw.Name, color, sw.Confidence, strings.Join(sw.Reasons, ", ")))
 	}
+	if query != nil {
+		if len(query.Plugins) > 0 {
+			s.add("Plugins", term.Sanitize(strings.Join(query.Plugins, "\n")))
+		}
+		if query.Whitelist != "" {
+			s.add("Whitelist", formatBool(query.Whitelist == "on", "On", "Off"))
+		}
+	}
+	s.add("Edition", term.Sanitize(status.Edition))
+	s.add("Game Mode", fmt.Sprintf("%v "+term.Gray+"(%v)", term.Sanitize(status.GameMode.Name), status.GameMode.ID))
 }
 
-func printBedrock(status mcpe.StatusResponse) {
-	printLine("Name", mcpe.LegacyTextAnsi(status.Name))
-	printLine("Level", mcpe.LegacyTextAnsi(status.Level))
-	printLatency(status.Latency)
-	printLine("Version", fmt.Sprintf("%v "+term.Gray+"(%v)", status.Version.Name, status.Version.Protocol))
-	printPlayers(status.Players.Online, status.Players.Max, nil)
-	printLine("Edition", status.Edition)
-	printLine("Game Mode", fmt.Sprintf("%v "+term.Gray+"(%v)", status.GameMode.Name, status.GameMode.ID))
+func raknetFields(s *section, probe mcpe.ProbeResponse, status mcpe.StatusResponse) {
+	v := term.Green + "Joinable"
+	if probe.Protocol != mcpe.RaknetProtocol {
+		v += fmt.Sprintf(term.Gray+" (protocol %v, expected %v)", probe.Protocol, mcpe.RaknetProtocol)
+	} else {
+		v += fmt.Sprintf(term.Gray+" (protocol %v)", probe.Protocol)
+	}
+	s.addSeverity(severityGood, "RakNet", v)
+
+	guid := strconv.FormatUint(uint64(probe.GUID), 10)
+	sev := severityNone
+	if status.ID != "" && status.ID != guid && status.ID != strconv.FormatInt(probe.GUID, 10) {
+		guid += "\n" + term.DarkYellow + "Pong advertised " + term.Sanitize(status.ID) + "; is this a proxy?"
+		sev = severityWarn
+	}
+	s.addSeverity(sev, "GUID", guid)
+	s.add("MTU", probe.MTU)
+	s.add("Security", formatBool(!probe.Security, "Off", "On"))
+
+	if len(probe.Ports) > 0 {
+		var ss []string
+		for _, port := range []uint16{status.Port.IPv4, status.Port.IPv6} {
+			open, ok := probe.Ports[port]
+			if !ok {
+				continue
+			}
+			family := "IPv4"
+			if port == status.Port.IPv6 {
+				family = "IPv6"
+			}
+			ss = append(ss, fmt.Sprintf("%v "+term.Gray+"(%v) ", port, family)+formatBool(open, "Open", "Closed"))
+		}
+		s.add("Advertised ports", strings.Join(ss, "\n"))
+	}
 }
 
-func printQuery(query mc.QueryResponse) {
-	prev := lines
-	if !cfg.status {
-		printMotd(mc.LegacyTextAnsi(query.Motd))
-		printLatency(query.Latency)
-		printLine("Version", mc.LegacyTextAnsi(query.Version))
-		printPlayers(query.Players.Online, query.Players.Max, query.Players.Sample)
+// queryFields adds the query response fields.
+// General info is only added if full is true, as it duplicates the status response otherwise.
+func queryFields(s *section, query mc.QueryResponse, full bool) {
+	if full {
+		motdField(s, mc.LegacyTextAnsi(query.Motd))
+		latencyField(s, query.Latency, nil)
+		s.add("Version", mc.LegacyTextAnsi(query.Version))
+		playersField(s, query.Players.Online, query.Players.Max, query.Players.Sample)
 	}
 	if query.Software != "" {
-		printLine("Software", query.Software)
+		s.add("Software", term.Sanitize(query.Software))
 	}
 	if len(query.Plugins) > 0 {
-		printLine("Plugins", strings.Join(query.Plugins, "\n"))
+		s.add("Plugins", term.Sanitize(strings.Join(query.Plugins, "\n")))
 	}
-	if lines == prev {
-		printLine("Query", term.Green+"Enabled")
+	if query.Whitelist != "" {
+		s.add("Whitelist", formatBool(query.Whitelist == "on", "On", "Off"))
+	}
+	if len(s.fields) == 0 {
+		s.addSeverity(severityGood, "Query", term.Green+"Enabled")
 	}
 }
 
-func printResult[T any](result result[T], label string, fn func(T), failed string) {
-	if result.success {
+// addResult adds the fields of result using fn if it succeeded,
+// or a single field describing the failure otherwise.
+// If failed is not empty, it is shown instead of "Failed" or "Timed out".
+func addResult[T any](s *section, result result[T], label string, fn func(T), failed string) {
+	if result.pending {
+		s.addPending(label)
+	} else if result.success {
 		fn(result.v)
 	} else {
-		if failed != "" {
-			printLine(label, failed)
+		if failed != "" && result.err != nil {
+			s.addSeverity(severityBad, label, failed+" "+formatErr(label, result.err))
+		} else if failed != "" {
+			s.addSeverity(severityBad, label, failed)
 		} else if result.err != nil {
-			printErr(label, result.err)
+			addErr(s, label, result.err)
 		} else {
-			printTimeout(label)
+			addTimeout(s, label)
 		}
 	}
 }
 
-func printResults(results *results) {
+// newReport extracts the fields to output from results.
+func newReport(results *results) *report {
+	r := &report{}
 	host, port := cfg.host, cfg.port
-
-	if cfg.icon.enabled && (!cfg.status || !results.status.success) {
-		printIcon(nil)
-	}
+	status := get(results, statusProbe)
+	bedrock := get(results, bedrockProbe)
+	raknet := get(results, raknetProbe)
+	query := get(results, queryProbe)
+	r.bedrock = cfg.bedrock.enabled
+	r.iconPending = status.pending
+	crossplay := cfg.crossplay
 
 	if cfg.status {
-		result := results.status
-		if !result.success {
-			cfg.status = false
-		}
-		s := "Status"
-		if results.bedrock.success {
-			s = "Java"
+		label := "Status"
+		if bedrock.success {
+			label = "Java"
 		}
-		printResult(results.status, s, func(status mc.StatusResponse) {
+		s := r.section("Status")
+		addResult(s, status, label, func(status mc.StatusResponse) {
 			host, port = status.Host, status.Port
-			printStatus(&status)
+			r.icon = status.Icon
+			statusFields(s, &status)
 		}, term.Red+"Offline")
 	}
 
-	if cfg.crossplay && !results.status.success && results.bedrock.success {
-		cfg.bedrock.enabled = true
-		cfg.crossplay = false
+	// Show the Bedrock server instead of the crossplay section if it is all there is
+	if crossplay && !status.pending && !status.success && bedrock.success {
+		r.bedrock = true
+		crossplay = false
 	}
-	if cfg.bedrock.enabled {
-		printResult(results.bedrock, "Bedrock", func(status mcpe.StatusResponse) {
+	if r.bedrock {
+		s := r.section("Bedrock")
+		addResult(s, bedrock, "Bedrock", func(status mcpe.StatusResponse) {
 			port = cfg.bedrock.port
-			printBedrock(status)
+			var probe *mcpe.ProbeResponse
+			if raknet.success {
+				probe = &raknet.v
+			}
+			var q *mc.QueryResponse
+			if query.success {
+				q = &query.v
+			}
+			bedrockFields(s, status, probe, q)
 		}, term.Red+"Offline")
 	}
 
+	if r.bedrock && cfg.raknet && bedrock.success {
+		s := r.section("RakNet")
+		addResult(s, raknet, "RakNet", func(probe mcpe.ProbeResponse) {
+			raknetFields(s, probe, bedrock.v)
+		}, term.Red+"Not joinable")
+	}
+
 	if cfg.query.enabled {
-		result := results.query
-		printResult(result, "Query", func(query mc.QueryResponse) {
-			port = query.Port
-			printQuery(query)
-		}, term.Red+"Disabled")
-	}
-
-	if cfg.crossplay {
-		printResult(results.bedrock, "Crossplay", func(status mcpe.StatusResponse) {
-			printLine("Crossplay", term.Green+"Yes")
-		}, term.Red+"No")
-		if !results.bedrock.success {
-			cfg.crossplay = false
+		s := r.section("Query")
+		if r.bedrock && bedrock.success && query.success {
+			// Already merged into the Bedrock section
+			s.addSeverity(severityGood, "Query", term.Green+"Enabled")
+		} else {
+			addResult(s, query, "Query", func(query mc.QueryResponse) {
+				port = query.Port
+				queryFields(s, query, !cfg.status || !status.success)
+			}, term.Red+"Disabled")
+		}
+	}
+
+	if crossplay {
+		s := r.section("Crossplay")
+		if bedrock.pending || status.pending || query.pending {
+			s.addPending("Crossplay")
+		} else if bedrock.success {
+			crossplayFields(s, newCrossplay(results))
+		} else {
+			s.addSeverity(severityBad, "Crossplay", term.Red+"No")
+			crossplay = false
 		}
 	}
 
-	printNetInfo(host, port)
+	if !offline() {
+		netFields(r.section("Network"), host, port, r.bedrock, crossplay)
+	}
 
+	s := r.section("Checks")
 	if cfg.blocked {
-		printResult(results.blocked, "Blocked", func(blocked string) {
-			printLine("Blocked", formatBool(blocked == "", "No", fmt.Sprintf("Yes %v(%v)", term.Gray, blocked)))
+		addResult(s, get(results, blockedProbe), "Blocked", func(blocked string) {
+			s.addSeverity(severityBool(blocked == ""), "Blocked", formatBool(blocked == "", "No", fmt.Sprintf("Yes %v(%v)", term.Gray, blocked)))
 		}, "")
 	}
 
 	if cfg.cracked {
-		printResult(results.cracked, "Cracked", func(crackedWhitelisted crackedWhitelisted) {
-			printLine("Cracked", formatBool(crackedWhitelisted.cracked, term.Reset+"Yes", term.Reset+"No"))
-			if crackedWhitelisted.cracked {
-				printLine("Whitelist", formatBool(!crackedWhitelisted.whitelisted, "Off", "On"))
+		addResult(s, get(results, crackedProbe), "Cracked", func(login mc.LoginResponse) {
+			s.add("Cracked", formatBool(login.Cracked, term.Reset+"Yes", term.Reset+"No"))
+			if login.Cracked {
+				s.add("Whitelist", formatBool(!login.Whitelisted, "Off", "On"))
+			}
+			if e := login.Encryption; e != nil {
+				encryptionFields(s, *e)
 			}
 		}, "")
 	}
 
 	if cfg.rcon.enabled {
-		printResult(results.rcon, "RCON", func(enabled bool) {
-			printLine("RCON", formatBool(!enabled, "Disabled", "Enabled"))
+		addResult(s, get(results, rconProbe), "RCON", func(enabled bool) {
+			s.addSeverity(severityBool(!enabled), "RCON", formatBool(!enabled, "Disabled", "Enabled"))
 		}, "")
 	}
 
-	if cfg.palette {
-		printPalette()
+	if cracked := get(results, crackedProbe); cfg.cracked && cracked.success && cracked.v.Cracked && !cracked.v.Whitelisted {
+		insideFields(r.section("Inside"), cracked.v, get(results, packProbe))
 	}
 
-	if cfg.icon.enabled && term.ColorSupport != term.NoColorSupport && lines < int(iconHeight())+1 {
-		fmt.Print(strings.Repeat("\n", int(iconHeight())-lines+1))
-	} else {
-		fmt.Print("\n")
-	}
+	return r
 }
 
-func printPalette() {
-	const codes = "0123456789abcdef"
-	var b strings.Builder
-	b.WriteRune('\n')
-	if cfg.icon.enabled {
-		b.WriteString(term.Fwd(cfg.icon.size + padding))
-	}
-	for _, code := range codes[:len(codes)/2] {
-		b.WriteString(term.Bg(mc.ParseColor(code)))
-		b.WriteString("   ")
-	}
-	b.WriteString(term.Reset)
-	b.WriteRune('\n')
-	if cfg.icon.enabled {
-		b.WriteString(term.Fwd(cfg.icon.size + padding))
-	}
-	for _, code := range codes[len(codes)/2:] {
-		b.WriteString(term.Bg(mc.ParseColor(code)))
-		b.WriteString("   ")
-	}
-	lines += 3
-	if cfg.bedrock.enabled {
-		const codes = "ghijmnpqstuv"
-		b.WriteString(term.Reset)
-		b.WriteRune('\n')
-		if cfg.icon.enabled {
-			b.WriteString(term.Fwd(cfg.icon.size + padding))
-		}
-		for _, code := range codes {
-			b.WriteString(term.Bg(mcpe.ParseColor(code)))
-			b.WriteString("  ")
-		}
-		lines++
+// encryptionFields adds the online mode details of an Encryption Request.
+// Addresses sharing a key fingerprint lead to the same server process, such as the backends of a proxy network.
+func encryptionFields(s *section, e mc.EncryptionRequest) {
+	s.add("Public key", fmt.Sprintf(term.Reset+"RSA %v bits\n"+term.Reset+"SHA-256 "+term.Gray+"%v", e.KeySize, e.Fingerprint))
+	if e.ServerID != "" {
+		s.add("Server ID", term.Sanitize(e.ServerID))
+	}
+	if !e.Authenticate {
+		s.add("Authentication", term.Reset+"Off "+term.Gray+"(the client does not join through the session server)")
 	}
-	b.WriteString(term.Reset)
-	b.WriteRune('\n')
-	fmt.Print(b.String())
 }
 
 func formatBool(bool bool, t, f string) string {
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"bhv.sh/minefetch/mc"
+	"bhv.sh/minefetch/mcpe"
+)
+
+var statusProbe = register(probe[mc.StatusResponse]{
+	name:    "status",
+	enabled: func() bool { return cfg.status },
+	timeout: &cfg.timeouts.status,
+	budget: func(timeout time.Duration) time.Duration {
+		budget := timeout
+		if cfg.statusRetry {
+			budget += timeout
+		}
+		return budget + pingBudget(timeout)
+	},
+	run: func(ctx context.Context, client *mc.Client, results *results) (status mc.StatusResponse, err error) {
+		address := cfg.host
+		if cfg.port != 0 {
+			address = mc.JoinHostPort(cfg.host, cfg.port)
+		}
+		status, err = client.StatusPing(ctx, address, int(cfg.count), cfg.interval)
+		// Some servers only respond to a second request
+		var e *mc.Error
+		if cfg.statusRetry && errors.As(err, &e) && e.Kind != mc.KindNotFound && e.Kind != mc.KindRefused {
+			status, err = client.StatusPing(ctx, address, int(cfg.count), cfg.interval)
+		}
+		return
+	},
+})
+
+// pingBudget returns the time needed for the pings following the first one.
+func pingBudget(timeout time.Duration) time.Duration {
+	if cfg.count > 1 {
+		return time.Duration(cfg.count) * max(cfg.interval, timeout)
+	}
+	return 0
+}
+
+var bedrockProbe = register(probe[mcpe.StatusResponse]{
+	name:    "bedrock",
+	enabled: func() bool { return cfg.bedrock.enabled || cfg.crossplay },
+	timeout: &cfg.timeouts.bedrock,
+	budget:  func(timeout time.Duration) time.Duration { return timeout + pingBudget(timeout) },
+	run: func(ctx context.Context, client *mc.Client, results *results) (mcpe.StatusResponse, error) {
+		return client.BedrockPing(ctx, mc.JoinHostPort(cfg.host, cfg.bedrock.port), int(cfg.count), cfg.interval)
+	},
+})
+
+var raknetProbe = register(probe[mcpe.ProbeResponse]{
+	name:    "raknet",
+	deps:    []runner{bedrockProbe},
+	enabled: func() bool { return cfg.raknet && (cfg.bedrock.enabled || cfg.crossplay) },
+	timeout: &cfg.timeouts.bedrock,
+	// MTU discovery may take several attempts
+	budget: func(timeout time.Duration) time.Duration { return 4 * timeout },
+	run: func(ctx context.Context, client *mc.Client, results *results) (probe mcpe.ProbeResponse, err error) {
+		bedrock := get(results, bedrockProbe)
+		if !bedrock.success {
+			return probe, errSkipped
+		}
+		var ports []uint16
+		if bedrock.v.Port.IPv4 != 0 {
+			ports = append(ports, bedrock.v.Port.IPv4)
+		}
+		if bedrock.v.Port.IPv6 != 0 && net.ParseIP(cfg.host).To4() == nil && net.ParseIP(cfg.host) != nil {
+			ports = append(ports, bedrock.v.Port.IPv6)
+		}
+		return client.BedrockProbe(ctx, mc.JoinHostPort(cfg.host, cfg.bedrock.port), ports)
+	},
+})
+
+var queryProbe = register(probe[mc.QueryResponse]{
+	name:    "query",
+	enabled: func() bool { return cfg.query.enabled },
+	timeout: &cfg.timeouts.query,
+	run: func(ctx context.Context, client *mc.Client, results *results) (mc.QueryResponse, error) {
+		return client.Query(ctx, queryAddress())
+	},
+})
+
+// queryAddress returns the address to query, without a port if none is configured.
+func queryAddress() string {
+	port := cfg.query.port
+	if port == 0 {
+		port = cfg.port
+	}
+	// Bedrock servers answer queries on their RakNet port
+	if port == 0 && cfg.bedrock.enabled {
+		port = cfg.bedrock.port
+	}
+	if port == 0 {
+		return cfg.host
+	}
+	return mc.JoinHostPort(cfg.host, port)
+}
+
+var blockedProbe = register(probe[string]{
+	name:    "blocked",
+	enabled: func() bool { return cfg.blocked },
+	timeout: &cfg.timeouts.blocked,
+	run: func(ctx context.Context, client *mc.Client, results *results) (string, error) {
+		return client.IsBlocked(ctx, cfg.host)
+	},
+})
+
+var crackedProbe = register(probe[mc.LoginResponse]{
+	name:    "cracked",
+	deps:    []runner{statusProbe},
+	enabled: func() bool { return cfg.cracked },
+	timeout: &cfg.timeouts.cracked,
+	// Connecting, the login and the configuration phase are each given the timeout
+	budget: func(timeout time.Duration) time.Duration { return 3 * timeout },
+	run: func(ctx context.Context, client *mc.Client, results *results) (mc.LoginResponse, error) {
+		address := cfg.host
+		if cfg.port != 0 {
+			address = mc.JoinHostPort(cfg.host, cfg.port)
+		}
+		// Servers may reject logins from other versions before checking the session
+		if status := get(results, statusProbe); cfg.proto == 0 && status.success && status.v.Version.Protocol > 0 {
+			c := *client
+			c.Protocol = status.v.Version.Protocol
+			client = &c
+		}
+		return client.Login(ctx, address)
+	},
+})
+
+// packDownload is the outcome of downloading a resource pack.
+type packDownload struct {
+	info mc.ResourcePackInfo
+	err  error
+}
+
+var packProbe = register(probe[[]packDownload]{
+	name:    "pack",
+	deps:    []runner{crackedProbe},
+	enabled: func() bool { return cfg.cracked && cfg.pack },
+	timeout: &cfg.timeouts.pack,
+	run: func(ctx context.Context, client *mc.Client, results *results) (downloads []packDownload, err error) {
+		cracked := get(results, crackedProbe)
+		if !cracked.success || cracked.v.Config == nil {
+			return nil, errSkipped
+		}
+		for _, pack := range cracked.v.Config.ResourcePacks {
+			info, err := client.DownloadResourcePack(ctx, pack.URL)
+			downloads = append(downloads, packDownload{info, err})
+		}
+		return
+	},
+})
+
+var rconProbe = register(probe[bool]{
+	name:    "rcon",
+	enabled: func() bool { return cfg.rcon.enabled },
+	timeout: &cfg.timeouts.rcon,
+	run: func(ctx context.Context, client *mc.Client, results *results) (bool, error) {
+		enabled, _ := client.RCON(ctx, mc.JoinHostPort(cfg.host, cfg.rcon.port))
+		return enabled, nil
+	},
+})
@@ -6,12 +6,12 @@ import (
 
 func printRawResults(results *results) {
 	if cfg.status {
-		fmt.Println(results.status.v.Raw)
+		fmt.Println(get(results, statusProbe).v.Raw)
 	}
 	if cfg.bedrock.enabled {
-		fmt.Println(results.bedrock.v.Raw)
+		fmt.Println(get(results, bedrockProbe).v.Raw)
 	}
 	if cfg.query.enabled {
-		fmt.Println(results.query.v.Raw)
+		fmt.Println(get(results, queryProbe).v.Raw)
 	}
 }
@@ -0,0 +1,589 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"bhv.sh/minefetch/internal/wire"
+	"bhv.sh/minefetch/mc"
+)
+
+// captureVersion is the version of the capture file format.
+const captureVersion = 1
+
+// session is the capture being recorded with --record or replayed with --replay, if any.
+var session *capture
+
+// capture is everything exchanged with the server by each probe,
+// so that the output can be reproduced without the network.
+//
+// Args are the command line arguments the capture was recorded with, except --record.
+type capture struct {
+	Version int                      `json:"version"`
+	Args    []string                 `json:"args"`
+	Probes  map[string]*probeCapture `json:"probes"`
+
+	replay bool
+	mu     sync.Mutex
+}
+
+// probeCapture holds the lookups and connections of a single probe, in order.
+type probeCapture struct {
+	Lookups []*lookupCapture `json:"lookups,omitempty"`
+	Conns   []*connCapture   `json:"conns,omitempty"`
+}
+
+// lookupCapture is a single SRV or IP address lookup.
+type lookupCapture struct {
+	Type  string       `json:"type"` // "srv" or "ip"
+	Name  string       `json:"name"`
+	CNAME string       `json:"cname,omitempty"`
+	SRV   []*net.SRV   `json:"srv,omitempty"`
+	IPs   []net.IPAddr `json:"ips,omitempty"`
+	Err   *captureErr  `json:"err,omitempty"`
+
+	used bool
+}
+
+// connCapture is a single connection, with what was read and written in order.
+// Dial is the time it took to connect, and the time of each event is relative to when the connection was made.
+type connCapture struct {
+	Network string         `json:"network"`
+	Address string         `json:"address"`
+	Local   string         `json:"local,omitempty"`
+	Remote  string         `json:"remote,omitempty"`
+	Dial    time.Duration  `json:"dial"`
+	Err     *captureErr    `json:"err,omitempty"`
+	Events  []captureEvent `json:"events,omitempty"`
+
+	used bool
+}
+
+// captureEvent is a single read or write.
+// For UDP, each read or write is a single datagram.
+// EOF marks the end of a TCP stream.
+type captureEvent struct {
+	T     time.Duration `json:"t"`
+	Write bool          `json:"write,omitempty"`
+	Data  []byte        `json:"data,omitempty"`
+	EOF   bool          `json:"eof,omitempty"`
+	Err   *captureErr   `json:"err,omitempty"`
+}
+
+// captureErr is an error that is replayed as one of the same kind.
+type captureErr struct {
+	Kind    mc.ErrorKind `json:"kind"`
+	Message string       `json:"message"`
+}
+
+func newCaptureErr(err error) *captureErr {
+	if err == nil {
+		return nil
+	}
+	return &captureErr{mc.ClassifyError(err), err.Error()}
+}
+
+// replayedError is a recorded error, which unwraps to an error of the same kind.
+type replayedError struct {
+	msg string
+	err error
+}
+
+func (e *replayedError) Error() string { return e.msg }
+func (e *replayedError) Unwrap() error { return e.err }
+
+func (e *captureErr) error(name string) error {
+	if e == nil {
+		return nil
+	}
+	var err error
+	switch e.Kind {
+	case mc.KindNotFound:
+		return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	case mc.KindRefused:
+		err = syscall.ECONNREFUSED
+	case mc.KindReset:
+		err = syscall.ECONNRESET
+	case mc.KindTimeout:
+		err = os.ErrDeadlineExceeded
+	}
+	return &replayedError{e.Message, err}
+}
+
+// newCapture starts recording a capture of the probes run with args.
+func newCapture(args []string) *capture {
+	return &capture{Version: captureVersion, Args: args, Probes: make(map[string]*probeCapture)}
+}
+
+// loadCapture reads a capture to replay.
+func loadCapture(name string) (c *capture, err error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return
+	}
+	c = &capture{}
+	err = json.Unmarshal(b, c)
+	if err != nil {
+		return nil, fmt.Errorf("invalid capture: %w", err)
+	}
+	if c.Version != captureVersion {
+		return nil, fmt.Errorf("unsupported capture version: %v", c.Version)
+	}
+	c.replay = true
+	if c.Probes == nil {
+		c.Probes = make(map[string]*probeCapture)
+	}
+	return
+}
+
+// save writes the capture to the file name.
+func (c *capture) save(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(b, '\n'), 0o644)
+}
+
+// recordArgs returns args without --record and its value.
+func recordArgs(args []string) (out []string) {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--record":
+			i++
+		case strings.HasPrefix(args[i], "--record="):
+		default:
+			out = append(out, args[i])
+		}
+	}
+	return
+}
+
+// attach makes client record to or replay from the capture of the probe name.
+func (c *capture) attach(name string, client *mc.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := c.Probes[name]
+	if p == nil {
+		p = &probeCapture{}
+		c.Probes[name] = p
+	}
+	if c.replay {
+		client.Dialer = &replayDialer{c, p}
+		client.Resolver = &replayResolver{c, p}
+		return
+	}
+	client.Dialer = &recordDialer{c, p, client.Dialer}
+	client.Resolver = &recordResolver{c, p, client.Resolver}
+}
+
+// recordDialer records the connections made by d, or a net.Dialer if it is nil.
+type recordDialer struct {
+	c *capture
+	p *probeCapture
+	d mc.Dialer
+}
+
+func (d *recordDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := d.d
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, network, address)
+	cc := &connCapture{Network: network, Address: address, Dial: time.Since(start), Err: newCaptureErr(err)}
+	if err == nil {
+		cc.Local, cc.Remote = conn.LocalAddr().String(), conn.RemoteAddr().String()
+	}
+	d.c.mu.Lock()
+	d.p.Conns = append(d.p.Conns, cc)
+	d.c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return &recordConn{conn, d.c, cc, time.Now()}, nil
+}
+
+// recordConn records what is read from and written to a connection.
+// Timeouts are not recorded, as they are replayed by waiting for the next event.
+type recordConn struct {
+	net.Conn
+	c     *capture
+	cc    *connCapture
+	start time.Time
+}
+
+func (c *recordConn) Read(b []byte) (n int, err error) {
+	n, err = c.Conn.Read(b)
+	e := captureEvent{T: time.Since(c.start), Data: bytes.Clone(b[:n])}
+	switch {
+	case errors.Is(err, io.EOF):
+		e.EOF = true
+	case errors.Is(err, os.ErrDeadlineExceeded), errors.Is(err, net.ErrClosed):
+		// Not recorded
+	case err != nil:
+		e.Err = newCaptureErr(err)
+	}
+	if n > 0 || e.EOF || e.Err != nil {
+		c.record(e)
+	}
+	return
+}
+
+func (c *recordConn) Write(b []byte) (n int, err error) {
+	n, err = c.Conn.Write(b)
+	c.record(captureEvent{T: time.Since(c.start), Write: true, Data: bytes.Clone(b[:n]), Err: newCaptureErr(err)})
+	return
+}
+
+func (c *recordConn) record(e captureEvent) {
+	c.c.mu.Lock()
+	defer c.c.mu.Unlock()
+	c.cc.Events = append(c.cc.Events, e)
+}
+
+// recordResolver records the lookups made by r, or net.DefaultResolver if it is nil.
+type recordResolver struct {
+	c *capture
+	p *probeCapture
+	r mc.Resolver
+}
+
+func (r *recordResolver) resolver() mc.Resolver {
+	if r.r != nil {
+		return r.r
+	}
+	return net.DefaultResolver
+}
+
+func (r *recordResolver) LookupSRV(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error) {
+	cname, addrs, err = r.resolver().LookupSRV(ctx, service, proto, name)
+	r.record(&lookupCapture{Type: "srv", Name: "_" + service + "._" + proto + "." + name, CNAME: cname, SRV: addrs, Err: newCaptureErr(err)})
+	return
+}
+
+func (r *recordResolver) LookupIPAddr(ctx context.Context, host string) (addrs []net.IPAddr, err error) {
+	addrs, err = r.resolver().LookupIPAddr(ctx, host)
+	r.record(&lookupCapture{Type: "ip", Name: host, IPs: addrs, Err: newCaptureErr(err)})
+	return
+}
+
+func (r *recordResolver) record(l *lookupCapture) {
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
+	r.p.Lookups = append(r.p.Lookups, l)
+}
+
+// replayResolver answers lookups with the recorded results, in order.
+// Lookups that were not recorded fail as if the domain does not exist.
+type replayResolver struct {
+	c *capture
+	p *probeCapture
+}
+
+func (r *replayResolver) lookup(typ, name string) *lookupCapture {
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
+	var found *lookupCapture
+	for _, l := range r.p.Lookups {
+		if l.Type == typ && l.Name == name && (found == nil || found.used) {
+			found = l
+		}
+	}
+	if found == nil {
+		return &lookupCapture{Err: &captureErr{Kind: mc.KindNotFound}}
+	}
+	// Repeated lookups get the last result
+	found.used = true
+	return found
+}
+
+func (r *replayResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	l := r.lookup("srv", "_"+service+"._"+proto+"."+name)
+	return l.CNAME, l.SRV, l.Err.error(name)
+}
+
+func (r *replayResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	l := r.lookup("ip", host)
+	return l.IPs, l.Err.error(host)
+}
+
+// replayDialer makes connections that replay the recorded ones in order.
+type replayDialer struct {
+	c *capture
+	p *probeCapture
+}
+
+func (d *replayDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.c.mu.Lock()
+	var cc *connCapture
+	for _, c := range d.p.Conns {
+		if !c.used && c.Network == network && c.Address == address {
+			cc = c
+			cc.used = true
+			break
+		}
+	}
+	d.c.mu.Unlock()
+	if cc == nil {
+		return nil, fmt.Errorf("no recorded connection to %v", address)
+	}
+
+	select {
+	case <-time.After(cc.Dial):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if cc.Err != nil {
+		return nil, cc.Err.error(address)
+	}
+	return newReplayConn(cc), nil
+}
+
+// replayConn is a connection replaying a recorded one.
+//
+// Reads return the recorded data once as much time has passed since the connection was made as when it was recorded.
+// Writes are discarded.
+//
+// Values derived from the clock, such as query session IDs and ping times,
+// differ between the recorded and the replayed writes, but servers echo them.
+// They are replaced in the responses that echo them, as found by echoOf.
+type replayConn struct {
+	cc      *connCapture
+	start   time.Time
+	reads   []captureEvent
+	writes  []replayWrite
+	echoes  []echo
+	readPos int
+	rest    []byte
+
+	mu       sync.Mutex
+	deadline time.Time
+	wake     chan struct{}
+	closed   bool
+}
+
+// replayWrite is a recorded write, and the position in the stream of reads of the data read after it.
+type replayWrite struct {
+	captureEvent
+	readPos int
+}
+
+// echo is a field derived from the clock in a replayed write, which the server copies into its response.
+// Over TCP, the field is at pos in the stream of reads.
+// Over UDP, it is at off in the datagrams starting with the packet ID id.
+type echo struct {
+	pos      int
+	id       byte
+	off      int
+	old, new []byte
+}
+
+func newReplayConn(cc *connCapture) *replayConn {
+	c := &replayConn{cc: cc, start: time.Now(), wake: make(chan struct{}, 1)}
+	var readPos int
+	for _, e := range cc.Events {
+		if e.Write {
+			c.writes = append(c.writes, replayWrite{e, readPos})
+		} else {
+			c.reads = append(c.reads, e)
+			readPos += len(e.Data)
+		}
+	}
+	return c
+}
+
+// echoOf returns the echoed field of the write b, recorded as w, if its value changed.
+//
+// These are the payload of Java Edition pings, which is echoed in the pong that follows,
+// the session ID of query requests, which is echoed in responses of the same type,
+// and the time of RakNet unconnected pings, which is echoed in pongs.
+func echoOf(network string, w replayWrite, b []byte) (e echo, ok bool) {
+	data := w.Data
+	if len(data) != len(b) {
+		return
+	}
+	if !strings.HasPrefix(network, "udp") {
+		// A Ping Request is 9 bytes long, and so is the Pong Response
+		if len(b) == 10 && b[0] == 9 && b[1] == byte(wire.StatusPacketIdPingRequest) && bytes.Equal(data[:2], b[:2]) {
+			e = echo{pos: w.readPos + 2, old: data[2:], new: b[2:]}
+		}
+	} else {
+		switch {
+		case len(b) >= 7 && binary.BigEndian.Uint16(b) == wire.QueryMagic && bytes.Equal(data[:3], b[:3]):
+			e = echo{id: b[2], off: 1, old: data[3:7], new: b[3:7]}
+		case len(b) >= 9 && b[0] == wire.RakNetPacketIdUnconnectedPing && data[0] == b[0]:
+			e = echo{id: wire.RakNetPacketIdUnconnectedPong, off: 1, old: data[1:9], new: b[1:9]}
+		}
+	}
+	return e, e.old != nil && !bytes.Equal(e.old, e.new)
+}
+
+// repair returns the recorded data read at pos in the stream,
+// with the fields echoed from the recorded writes replaced by those of the replayed writes.
+func (c *replayConn) repair(data []byte, pos int) []byte {
+	udp := strings.HasPrefix(c.cc.Network, "udp")
+	out := data
+	for _, e := range c.echoes {
+		// The field is data[i:j], and old[k:] in the recorded write
+		var i, j, k int
+		if udp {
+			if len(data) == 0 || data[0] != e.id {
+				continue
+			}
+			i, j = e.off, e.off+len(e.old)
+		} else {
+			i, j = max(e.pos-pos, 0), min(e.pos+len(e.old)-pos, len(data))
+			k = i + pos - e.pos
+		}
+		if i >= j || j > len(data) || !bytes.Equal(data[i:j], e.old[k:k+j-i]) {
+			continue
+		}
+		if &out[0] == &data[0] {
+			out = bytes.Clone(data)
+		}
+		copy(out[i:j], e.new[k:])
+	}
+	return out
+}
+
+func (c *replayConn) Read(b []byte) (n int, err error) {
+	for {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return 0, net.ErrClosed
+		}
+		if len(c.rest) > 0 {
+			n = copy(b, c.rest)
+			c.rest = c.rest[n:]
+			c.mu.Unlock()
+			return
+		}
+
+		wait := time.Duration(-1)
+		if len(c.reads) > 0 {
+			wait = time.Until(c.start.Add(c.reads[0].T))
+		}
+		if len(c.reads) > 0 && wait <= 0 {
+			e := c.reads[0]
+			c.reads = c.reads[1:]
+			data := c.repair(e.Data, c.readPos)
+			c.readPos += len(e.Data)
+			n = copy(b, data)
+			// The rest of a datagram that does not fit is discarded
+			if !strings.HasPrefix(c.cc.Network, "udp") {
+				c.rest = data[n:]
+			}
+			switch {
+			case e.EOF && n == 0:
+				err = io.EOF
+			case e.Err != nil && n == 0:
+				err = e.Err.error(c.cc.Address)
+			}
+			c.mu.Unlock()
+			return
+		}
+
+		if !c.deadline.IsZero() {
+			until := time.Until(c.deadline)
+			if until <= 0 {
+				c.mu.Unlock()
+				return 0, os.ErrDeadlineExceeded
+			}
+			if wait < 0 || until < wait {
+				wait = until
+			}
+		}
+		c.mu.Unlock()
+
+		if wait < 0 {
+			<-c.wake
+			continue
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-c.wake:
+			t.Stop()
+		}
+	}
+}
+
+func (c *replayConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	if len(c.writes) == 0 {
+		return len(b), nil
+	}
+	e := c.writes[0]
+	c.writes = c.writes[1:]
+	if echo, ok := echoOf(c.cc.Network, e, b); ok {
+		echo.new = bytes.Clone(echo.new)
+		c.echoes = append(c.echoes, echo)
+	}
+	if e.Err != nil {
+		return len(e.Data), e.Err.error(c.cc.Address)
+	}
+	return len(b), nil
+}
+
+func (c *replayConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	c.notify()
+	return nil
+}
+
+// notify wakes up a blocked Read.
+func (c *replayConn) notify() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (c *replayConn) LocalAddr() net.Addr  { return replayAddr(c.cc.Network, c.cc.Local) }
+func (c *replayConn) RemoteAddr() net.Addr { return replayAddr(c.cc.Network, c.cc.Remote) }
+
+func replayAddr(network, address string) net.Addr {
+	ap, _ := netip.ParseAddrPort(address)
+	if strings.HasPrefix(network, "udp") {
+		return net.UDPAddrFromAddrPort(ap)
+	}
+	return net.TCPAddrFromAddrPort(ap)
+}
+
+func (c *replayConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+func (c *replayConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadline = t
+	c.notify()
+	return nil
+}
+
+func (c *replayConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"bhv.sh/minefetch/internal/wire"
+	"bhv.sh/minefetch/mc"
+	"bhv.sh/minefetch/mc/mctest"
+	"bhv.sh/minefetch/mcpe"
+)
+
+// sessionResults are the results of the probes run by runSession.
+type sessionResults struct {
+	status  mc.StatusResponse
+	query   mc.QueryResponse
+	bedrock mcpe.StatusResponse
+}
+
+// runSession runs a status ping, a query and a Bedrock ping with clients attached to c.
+func runSession(t *testing.T, c *capture, java, query, bedrock string) (r sessionResults) {
+	t.Helper()
+	ctx := context.Background()
+	client := func(name string) *mc.Client {
+		client := &mc.Client{Timeout: time.Second}
+		c.attach(name, client)
+		return client
+	}
+	var err error
+	r.status, err = client("status").StatusPing(ctx, java, 2, 10*time.Millisecond)
+	if err != nil || r.status.PingErr != nil || slices.Contains(r.status.Latencies, 0) {
+		t.Fatalf("status: %v, latencies %v", err, r.status.Latencies)
+	}
+	r.query, err = client("query").Query(ctx, query)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	r.bedrock, err = client("bedrock").BedrockPing(ctx, bedrock, 2, 10*time.Millisecond)
+	if err != nil || slices.Contains(r.bedrock.Latencies, 0) {
+		t.Fatalf("bedrock: %v, latencies %v", err, r.bedrock.Latencies)
+	}
+	return
+}
+
+func TestRecordReplay(t *testing.T) {
+	java := &mctest.JavaServer{Status: `{"version":{"name":"Paper 1.21.4","protocol":769},"players":{"max":20,"online":1},"description":"A Minecraft Server"}`}
+	query := &mctest.QueryServer{
+		Values:  [][2]string{{"hostname", "A Minecraft Server"}, {"numplayers", "1"}, {"maxplayers", "20"}},
+		Players: []string{"Notch"},
+		Token:   1234,
+	}
+	bedrock := &mctest.BedrockServer{
+		Pong: "MCPE;Dedicated Server;766;1.21.50;3;10;1234;Bedrock level;Survival;1;19132;19133;",
+		GUID: 1234,
+	}
+	for _, s := range []interface{ Start() error }{java, query, bedrock} {
+		err := s.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec := newCapture(nil)
+	recorded := runSession(t, rec, java.Addr(), query.Addr(), bedrock.Addr())
+	java.Close()
+	query.Close()
+	bedrock.Close()
+
+	name := filepath.Join(t.TempDir(), "capture.json")
+	err := rec.save(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay, err := loadCapture(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Ping payloads and query session IDs change every second
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	replayed := runSession(t, replay, java.Addr(), query.Addr(), bedrock.Addr())
+
+	if replayed.status.Raw != recorded.status.Raw {
+		t.Errorf("replayed status %q, recorded %q", replayed.status.Raw, recorded.status.Raw)
+	}
+	if replayed.query.Raw != recorded.query.Raw {
+		t.Errorf("replayed query %q, recorded %q", replayed.query.Raw, recorded.query.Raw)
+	}
+	if replayed.bedrock.Raw != recorded.bedrock.Raw || replayed.bedrock.GUID != recorded.bedrock.GUID {
+		t.Errorf("replayed pong %q (%v), recorded %q (%v)", replayed.bedrock.Raw, replayed.bedrock.GUID, recorded.bedrock.Raw, recorded.bedrock.GUID)
+	}
+}
+
+func TestReplayEchoes(t *testing.T) {
+	old, new := make([]byte, 8), make([]byte, 8)
+	binary.BigEndian.PutUint64(old, 1)
+	binary.BigEndian.PutUint64(new, 2)
+
+	// The status response and the unrelated datagram contain the recorded ping time, but do not echo it
+	ping := append([]byte{9, byte(wire.StatusPacketIdPingRequest)}, old...)
+	pong := append([]byte{9, byte(wire.StatusPacketIdPongResponse)}, old...)
+	status := append([]byte{10, byte(wire.StatusPacketIdStatusResponse), 8}, old...)
+	tcp := &connCapture{Network: "tcp", Events: []captureEvent{
+		{Data: status},
+		{Write: true, Data: ping},
+		{Data: pong[:1]},
+		{Data: pong[1:]},
+	}}
+	conn := newReplayConn(tcp)
+	var got []byte
+	b := make([]byte, 64)
+	n, _ := conn.Read(b)
+	got = append(got, b[:n]...)
+	conn.Write(append([]byte{9, byte(wire.StatusPacketIdPingRequest)}, new...))
+	for range 2 {
+		n, _ = conn.Read(b)
+		got = append(got, b[:n]...)
+	}
+	want := slices.Concat(status, []byte{9, byte(wire.StatusPacketIdPongResponse)}, new)
+	if !bytes.Equal(got, want) {
+		t.Errorf("read %x over TCP, want %x", got, want)
+	}
+
+	unconnectedPing := func(t []byte) []byte {
+		return slices.Concat([]byte{wire.RakNetPacketIdUnconnectedPing}, t, wire.RakNetMagic[:], make([]byte, 8))
+	}
+	unrelated := slices.Concat([]byte{wire.RakNetPacketIdOpenConnectionReply1}, old)
+	udp := &connCapture{Network: "udp", Events: []captureEvent{
+		{Write: true, Data: unconnectedPing(old)},
+		{Data: unrelated},
+		{Data: slices.Concat([]byte{wire.RakNetPacketIdUnconnectedPong}, old)},
+	}}
+	conn = newReplayConn(udp)
+	conn.Write(unconnectedPing(new))
+	n, _ = conn.Read(b)
+	if !bytes.Equal(b[:n], unrelated) {
+		t.Errorf("read %x over UDP, want the unrelated datagram %x", b[:n], unrelated)
+	}
+	n, _ = conn.Read(b)
+	if want := slices.Concat([]byte{wire.RakNetPacketIdUnconnectedPong}, new); !bytes.Equal(b[:n], want) {
+		t.Errorf("read %x over UDP, want the pong %x", b[:n], want)
+	}
+}
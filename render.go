@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"slices"
+	"strings"
+
+	"bhv.sh/minefetch/internal/term"
+	"bhv.sh/minefetch/mc"
+	"bhv.sh/minefetch/mcpe"
+)
+
+// renderer outputs a report to stdout in a specific format.
+type renderer interface {
+	render(r *report)
+}
+
+// newRenderer returns the renderer for cfg.output.
+func newRenderer() renderer {
+	switch cfg.output {
+	case "plain":
+		return plainRenderer{}
+	case "json":
+		return jsonRenderer{}
+	case "markdown":
+		return markdownRenderer{}
+	case "html":
+		return htmlRenderer{}
+	}
+	return &terminalRenderer{}
+}
+
+// fieldLines returns the lines of f, limited to cfg.maxList.
+func fieldLines(f field) []string {
+	ss := append([]string{f.value}, f.items...)
+	if len(ss) == 1 && term.RemoveCsi(ss[0]) == "" {
+		ss[0] = term.Gray + "(empty)"
+	}
+	if cfg.maxList != 0 && uint(len(ss)) > cfg.maxList {
+		n := len(ss)
+		ss = ss[:cfg.maxList]
+		ss[len(ss)-1] = fmt.Sprintf(term.Gray+"(%v more)", n-int(cfg.maxList))
+	}
+	return ss
+}
+
+// trimLines is like fieldLines, but without the padding used for alignment in the terminal.
+func trimLines(f field) []string {
+	ss := fieldLines(f)
+	for i, v := range ss {
+		ss[i] = strings.TrimSpace(v)
+	}
+	return ss
+}
+
+const padding = 2
+
+// terminalRenderer prints fields next to the server icon, with colors and cursor movement.
+type terminalRenderer struct {
+	lines int
+}
+
+func (t *terminalRenderer) render(r *report) {
+	if cfg.icon.enabled {
+		printIcon(r.icon)
+	}
+	for _, s := range r.sections {
+		for _, f := range s.fields {
+			t.printField(f)
+		}
+	}
+	if cfg.palette {
+		t.printPalette(r.bedrock)
+	}
+	if cfg.icon.enabled && term.ColorSupport != term.NoColorSupport && t.lines < int(iconHeight())+1 {
+		fmt.Print(strings.Repeat("\n", int(iconHeight())-t.lines+1))
+	} else {
+		fmt.Print("\n")
+	}
+}
+
+func (t *terminalRenderer) printField(f field) {
+	ss := fieldLines(f)
+	if cfg.icon.enabled {
+		fmt.Print(term.Fwd(cfg.icon.size + padding))
+	}
+	fmt.Println(term.Bold + term.Blue + f.label + term.Reset + ": " + ss[0] + term.Reset)
+	for _, v := range ss[1:] {
+		fwd := uint(len(f.label)) + 2
+		if cfg.icon.enabled && term.ColorSupport != term.NoColorSupport {
+			fwd += cfg.icon.size + padding
+			fmt.Println(term.Fwd(fwd) + v + term.Reset)
+		} else {
+			fmt.Println(strings.Repeat(" ", int(fwd)) + v + term.Reset)
+		}
+	}
+	t.lines += len(ss)
+}
+
+func (t *terminalRenderer) printPalette(bedrock bool) {
+	const codes = "0123456789abcdef"
+	var b strings.Builder
+	b.WriteRune('\n')
+	if cfg.icon.enabled {
+		b.WriteString(term.Fwd(cfg.icon.size + padding))
+	}
+	for _, code := range codes[:len(codes)/2] {
+		b.WriteString(term.Bg(mc.ParseColor(code)))
+		b.WriteString("   ")
+	}
+	b.WriteString(term.Reset)
+	b.WriteRune('\n')
+	if cfg.icon.enabled {
+		b.WriteString(term.Fwd(cfg.icon.size + padding))
+	}
+	for _, code := range codes[len(codes)/2:] {
+		b.WriteString(term.Bg(mc.ParseColor(code)))
+		b.WriteString("   ")
+	}
+	t.lines += 3
+	if bedrock {
+		const codes = "ghijmnpqstuv"
+		b.WriteString(term.Reset)
+		b.WriteRune('\n')
+		if cfg.icon.enabled {
+			b.WriteString(term.Fwd(cfg.icon.size + padding))
+		}
+		for _, code := range codes {
+			b.WriteString(term.Bg(mcpe.ParseColor(code)))
+			b.WriteString("  ")
+		}
+		t.lines++
+	}
+	b.WriteString(term.Reset)
+	b.WriteRune('\n')
+	fmt.Print(b.String())
+}
+
+// plainRenderer prints fields without colors or the icon,
+// with list items aligned below the value.
+type plainRenderer struct{}
+
+func (plainRenderer) render(r *report) {
+	for _, s := range r.sections {
+		for _, f := range s.fields {
+			ss := fieldLines(f)
+			fmt.Println(f.label + ": " + ss[0])
+			for _, v := range ss[1:] {
+				fmt.Println(strings.Repeat(" ", len(f.label)+2) + v)
+			}
+		}
+	}
+}
+
+// jsonRenderer prints the report as a JSON object.
+// Lists are not limited by cfg.maxList.
+type jsonRenderer struct{}
+
+type jsonReport struct {
+	Sections []jsonSection `json:"sections"`
+}
+
+type jsonSection struct {
+	Name   string      `json:"name"`
+	Fields []jsonField `json:"fields"`
+}
+
+type jsonField struct {
+	Label    string   `json:"label"`
+	Value    string   `json:"value"`
+	Items    []string `json:"items,omitempty"`
+	Severity string   `json:"severity,omitempty"`
+}
+
+func (jsonRenderer) render(r *report) {
+	v := jsonReport{Sections: []jsonSection{}}
+	for _, s := range r.sections {
+		if len(s.fields) == 0 {
+			continue
+		}
+		js := jsonSection{Name: s.name}
+		for _, f := range s.fields {
+			f.value = strings.TrimSpace(f.value)
+			f.items = slices.Clone(f.items)
+			for i, v := range f.items {
+				f.items[i] = strings.TrimSpace(v)
+			}
+			js.Fields = append(js.Fields, jsonField{f.label, f.value, f.items, f.severity.String()})
+		}
+		v.Sections = append(v.Sections, js)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// markdownRenderer prints each section as a heading followed by a list of fields.
+type markdownRenderer struct{}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`,
+)
+
+func (markdownRenderer) render(r *report) {
+	first := true
+	for _, s := range r.sections {
+		if len(s.fields) == 0 {
+			continue
+		}
+		if !first {
+			fmt.Println()
+		}
+		first = false
+		fmt.Println("## " + s.name)
+		fmt.Println()
+		for _, f := range s.fields {
+			ss := trimLines(f)
+			fmt.Println("- **" + markdownEscaper.Replace(f.label) + ":** " + markdownEscaper.Replace(ss[0]))
+			for _, v := range ss[1:] {
+				fmt.Println("  - " + markdownEscaper.Replace(v))
+			}
+		}
+	}
+}
+
+// htmlRenderer prints the report as a standalone HTML document.
+// Each field value has the class of its severity, if any.
+type htmlRenderer struct{}
+
+func (htmlRenderer) render(r *report) {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<title>" + html.EscapeString(cfg.host) + "</title>\n")
+	b.WriteString("<style>.good { color: green; } .warn { color: darkorange; } .bad { color: red; }</style>\n")
+	b.WriteString("</head>\n<body>\n")
+	for _, s := range r.sections {
+		if len(s.fields) == 0 {
+			continue
+		}
+		b.WriteString("<section>\n<h2>" + html.EscapeString(s.name) + "</h2>\n<dl>\n")
+		for _, f := range s.fields {
+			ss := trimLines(f)
+			b.WriteString("<dt>" + html.EscapeString(f.label) + "</dt>\n<dd")
+			if f.severity != severityNone {
+				b.WriteString(` class="` + f.severity.String() + `"`)
+			}
+			b.WriteString(">" + html.EscapeString(ss[0]))
+			if len(ss) > 1 {
+				b.WriteString("\n<ul>\n")
+				for _, v := range ss[1:] {
+					b.WriteString("<li>" + html.EscapeString(v) + "</li>\n")
+				}
+				b.WriteString("</ul>\n")
+			}
+			b.WriteString("</dd>\n")
+		}
+		b.WriteString("</dl>\n</section>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+	fmt.Print(b.String())
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// severity tells renderers how a field should stand out.
+type severity int
+
+const (
+	severityNone severity = iota
+	severityGood
+	severityWarn
+	severityBad
+)
+
+func (s severity) String() string {
+	switch s {
+	case severityGood:
+		return "good"
+	case severityWarn:
+		return "warn"
+	case severityBad:
+		return "bad"
+	}
+	return ""
+}
+
+// severityBool returns severityGood if b is true, and severityBad otherwise.
+func severityBool(b bool) severity {
+	if b {
+		return severityGood
+	}
+	return severityBad
+}
+
+// field is a single labeled value of the output.
+//
+// value and items may contain term escape codes,
+// which are empty unless rendering to a terminal.
+// items are the lines listed below value, such as player names.
+// pending fields are placeholders for probes that have not finished yet.
+type field struct {
+	label    string
+	value    string
+	items    []string
+	severity severity
+	pending  bool
+}
+
+// section is a group of fields, usually from the same probe.
+type section struct {
+	name   string
+	fields []field
+}
+
+// add appends a field to s.
+// Lines of data after the first become the items of the field.
+func (s *section) add(label string, data any) {
+	s.addSeverity(severityNone, label, data)
+}
+
+// addSeverity is like add, but with a severity other than severityNone.
+func (s *section) addSeverity(sev severity, label string, data any) {
+	ss := strings.Split(fmt.Sprint(data), "\n")
+	s.fields = append(s.fields, field{label: label, value: ss[0], items: ss[1:], severity: sev})
+}
+
+// addPending appends a placeholder field to s.
+func (s *section) addPending(label string) {
+	s.fields = append(s.fields, field{label: label, pending: true})
+}
+
+// report is everything to output about a server, in order.
+//
+// icon is the PNG server icon, or nil for the default icon.
+// iconPending reports whether the icon is not known yet.
+// bedrock reports whether the server was shown as a Bedrock server.
+type report struct {
+	icon        []byte
+	iconPending bool
+	bedrock     bool
+	sections    []*section
+}
+
+// section appends an empty section to r and returns it.
+func (r *report) section(name string) *section {
+	s := &section{name: name}
+	r.sections = append(r.sections, s)
+	return s
+}
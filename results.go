@@ -1,100 +1,206 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"sync"
 	"time"
 
-	"bhv.sh/minefetch/internal/mc"
-	"bhv.sh/minefetch/internal/mcpe"
+	"bhv.sh/minefetch/mc"
 )
 
+// result is the outcome of a probe.
+// pending reports whether the probe is enabled but has not finished yet.
 type result[T any] struct {
 	v       T
 	err     error
 	success bool
+	pending bool
 }
 
-type crackedWhitelisted struct {
-	cracked     bool
-	whitelisted bool
+// A probe is a single check run against the server, such as a status request.
+//
+// Enabled probes run concurrently, except that a probe waits for its deps to finish first,
+// and may read their results from the store.
+// Each exchange with the server is limited by timeout, or cfg.timeout if it is unset or 0.
+// The whole probe is limited by budget, which is given the timeout of each exchange,
+// and defaults to that timeout.
+// A probe that times out is stored as a zero result, which is printed as a timeout.
+type probe[T any] struct {
+	name    string
+	deps    []runner
+	enabled func() bool
+	timeout *time.Duration
+	budget  func(timeout time.Duration) time.Duration
+	run     func(ctx context.Context, client *mc.Client, results *results) (T, error)
 }
 
+// runner is the part of a probe that does not depend on its result type.
+type runner interface {
+	probeName() string
+	dependencies() []runner
+	isEnabled() bool
+	runInto(ctx context.Context, client *mc.Client, results *results)
+}
+
+// probes is the registry of all probes, in registration order.
+var probes []runner
+
+// register adds p to the registry, and returns it for use as a key to its result.
+func register[T any](p probe[T]) *probe[T] {
+	probes = append(probes, &p)
+	return &p
+}
+
+func (p *probe[T]) probeName() string      { return p.name }
+func (p *probe[T]) dependencies() []runner { return p.deps }
+func (p *probe[T]) isEnabled() bool        { return p.enabled() }
+
+func (p *probe[T]) runInto(ctx context.Context, client *mc.Client, results *results) {
+	timeout := cfg.timeout
+	if p.timeout != nil {
+		timeout = cmp.Or(*p.timeout, timeout)
+	}
+	c := *client
+	c.Timeout = timeout
+	if session != nil {
+		session.attach(p.name, &c)
+	}
+	if cfg.trace {
+		c.Trace = tracer(p.name)
+	}
+	client = &c
+	if p.budget != nil {
+		timeout = p.budget(timeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ch := make(chan result[T], 1)
+	results.running.Go(func() {
+		v, err := p.run(ctx, client, results)
+		ch <- result[T]{v: v, err: err, success: err == nil}
+	})
+	select {
+	case r := <-ch:
+		if r.err != nil && ctx.Err() != nil {
+			// Failures caused by the deadline are timeouts
+			r = result[T]{}
+		}
+		results.set(p.name, r)
+	case <-ctx.Done():
+		results.set(p.name, result[T]{})
+	}
+}
+
+// errSkipped is returned by probes that did not run because a dependency failed.
+var errSkipped = errors.New("skipped because a dependency failed")
+
+// results stores the result of each probe by name. It is safe for concurrent use.
+//
+// Only the first result stored for a probe is kept,
+// so a probe that finishes after timing out cannot change what is printed.
+//
+// changed receives a value when a result is stored, and finished is closed once all probes are done.
+// running tracks the probe functions, which may outlive their budget.
 type results struct {
-	status  result[mc.StatusResponse]
-	bedrock result[mcpe.StatusResponse]
-	query   result[mc.QueryResponse]
-	blocked result[string]
-	cracked result[crackedWhitelisted]
-	rcon    result[bool]
+	mu       sync.Mutex
+	m        map[string]any
+	done     map[string]chan struct{}
+	changed  chan struct{}
+	finished chan struct{}
+	running  sync.WaitGroup
 }
 
-func getResults() *results {
-	var results results
-	var wg sync.WaitGroup
+func (r *results) set(name string, v any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.m[name]; !ok {
+		r.m[name] = v
+	}
+	select {
+	case r.changed <- struct{}{}:
+	default:
+	}
+}
 
-	if cfg.status {
-		wg.Go(func() {
-			address := cfg.host
-			if cfg.port != 0 {
-				address = mc.JoinHostPort(cfg.host, cfg.port)
-			}
-			status, err := mc.Status(address, cfg.proto)
-			results.status = result[mc.StatusResponse]{status, err, err == nil}
-		})
+// get returns the result of p, which is the zero result if p did not run,
+// and is pending if p has not finished yet.
+func get[T any](r *results, p *probe[T]) result[T] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.m[p.name].(result[T])
+	if !ok {
+		select {
+		case <-r.done[p.name]:
+		default:
+			v.pending = true
+		}
 	}
-	if cfg.bedrock.enabled || cfg.crossplay {
-		wg.Go(func() {
-			status, err := mcpe.Status(mc.JoinHostPort(cfg.host, cfg.bedrock.port))
-			results.bedrock = result[mcpe.StatusResponse]{status, err, err == nil}
-		})
+	return v
+}
+
+// wait blocks until all probes are done.
+func (r *results) wait() {
+	<-r.finished
+}
+
+// drain blocks until the functions of probes that timed out have returned too,
+// after which cfg may be changed.
+// They return soon after, as their context is canceled on timeout.
+func (r *results) drain() {
+	r.wait()
+	r.running.Wait()
+}
+
+// newClient returns a client configured by the command line flags.
+func newClient() *mc.Client {
+	return &mc.Client{
+		Timeout:  cfg.timeout,
+		Protocol: cfg.proto,
+		Proxy:    cfg.proxy.header,
+		Retry:    mc.Retry{Count: int(cfg.retry.count), Backoff: cfg.retry.backoff},
 	}
-	if cfg.query.enabled {
-		wg.Go(func() {
-			address := cfg.host
-			queryPort := cfg.query.port
-			if queryPort == 0 {
-				queryPort = cfg.port
-			}
-			if queryPort != 0 {
-				address = mc.JoinHostPort(cfg.host, queryPort)
-			}
-			query, err := mc.Query(address)
-			results.query = result[mc.QueryResponse]{query, err, err == nil}
-		})
+}
+
+// newResults returns an empty store for the results of all probes.
+func newResults() *results {
+	results := &results{
+		m:        make(map[string]any, len(probes)),
+		done:     make(map[string]chan struct{}, len(probes)),
+		changed:  make(chan struct{}, 1),
+		finished: make(chan struct{}),
 	}
-	if cfg.blocked {
-		wg.Go(func() {
-			blocked, err := mc.IsBlocked(cfg.host)
-			results.blocked = result[string]{blocked, err, err == nil}
-		})
+	for _, p := range probes {
+		results.done[p.probeName()] = make(chan struct{})
 	}
-	if cfg.cracked {
+	return results
+}
+
+// startProbes starts all enabled probes without waiting for them.
+func startProbes() *results {
+	results := newResults()
+	ctx := context.Background()
+	client := newClient()
+	var wg sync.WaitGroup
+	for _, p := range probes {
+		done := results.done[p.probeName()]
+		if !p.isEnabled() {
+			close(done)
+			continue
+		}
 		wg.Go(func() {
-			// TODO: use server protocol from status response?
-			address := cfg.host
-			if cfg.port != 0 {
-				address = mc.JoinHostPort(cfg.host, cfg.port)
+			defer close(done)
+			for _, dep := range p.dependencies() {
+				<-results.done[dep.probeName()]
 			}
-			cracked, whitelisted, err := mc.IsCracked(address, cfg.proto)
-			results.cracked = result[crackedWhitelisted]{crackedWhitelisted{cracked, whitelisted}, err, err == nil}
+			p.runInto(ctx, client, results)
 		})
 	}
-	if cfg.rcon.enabled {
-		wg.Go(func() {
-			enabled, _ := mc.IsRconEnabled(mc.JoinHostPort(cfg.host, cfg.rcon.port))
-			results.rcon = result[bool]{enabled, nil, true}
-		})
-	}
-
-	done := make(chan struct{})
-	timeout := time.After(cfg.timeout)
 	go func() {
-		defer close(done)
+		defer close(results.finished)
 		wg.Wait()
 	}()
-	select {
-	case <-done:
-	case <-timeout:
-	}
-	return &results
+	return results
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"bhv.sh/minefetch/mc"
+)
+
+var traceMu sync.Mutex
+
+// tracer returns a function printing the packets of probe to stderr, for use as mc.Client.Trace.
+// Probes run concurrently, so each packet is printed at once.
+func tracer(probe string) func(p mc.TracePacket) {
+	return func(p mc.TracePacket) {
+		b := &strings.Builder{}
+		b.WriteString(probe)
+		if p.Sent {
+			b.WriteString(" -> ")
+		} else {
+			b.WriteString(" <- ")
+		}
+		b.WriteString(p.Protocol)
+		if p.State != "" {
+			b.WriteString("/" + p.State)
+		}
+		fmt.Fprintf(b, " 0x%02x", p.ID)
+		if p.Name != "" {
+			b.WriteString(" " + p.Name)
+		}
+		fmt.Fprintf(b, ", length %d", p.Length)
+		if p.DataLength != 0 {
+			fmt.Fprintf(b, ", uncompressed %d", p.DataLength)
+		}
+		b.WriteByte('\n')
+		for _, line := range strings.SplitAfter(hex.Dump(p.Data), "\n") {
+			if line != "" {
+				b.WriteString("    " + line)
+			}
+		}
+
+		traceMu.Lock()
+		defer traceMu.Unlock()
+		os.Stderr.WriteString(b.String())
+	}
+}